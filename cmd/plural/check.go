@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+// checkBuild rebuilds every repo into a scratch copy of the workspace, and fails if the result differs
+// from the working tree.  The repo itself is never touched, so this is safe to run in CI
+func (p *Plural) checkBuild(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+	toplevel, err := git.Toplevel()
+	if err != nil {
+		return err
+	}
+
+	p.Client = wkspace.NewPackageCache(p.Client)
+	installations, err := p.buildInstallations(c.String("only"))
+	if err != nil {
+		return err
+	}
+
+	// the whole repo is copied, as an environment's workspace depends on the base files at its toplevel
+	scratch, err := scaffold.Scratch(toplevel)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	scratchRoot, err := p.buildIn(scratch, toplevel, root, installations)
+	if err != nil {
+		return err
+	}
+
+	changes, err := scaffold.Compare(root, scratchRoot, decryptContents)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		utils.Success("\nThe workspace is up to date with its inputs\n")
+		return nil
+	}
+
+	fmt.Println()
+	for _, change := range changes {
+		utils.Highlight("%s: %s\n", change.Action, change.Path)
+		fmt.Println(change.Diff)
+	}
+
+	msg := fmt.Sprintf("%d %s would change, run `plural build` and commit the result", len(changes), utils.Pluralize("file", "files", len(changes)))
+	return cli.NewExitError(msg, exitPendingChanges)
+}
+
+// buildIn runs builds with the scratch copy of toplevel as the working directory, returning to the
+// original directory once done.  It returns where the root of the workspace is within the copy
+func (p *Plural) buildIn(scratch, toplevel, root string, installations []*api.Installation) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(toplevel, cwd)
+	if err != nil {
+		return "", err
+	}
+	relRoot, err := filepath.Rel(toplevel, root)
+	if err != nil {
+		return "", err
+	}
+	defer os.Chdir(cwd)
+
+	if err := os.Chdir(scratch); err != nil {
+		return "", err
+	}
+	if _, err := git.Init(); err != nil {
+		return "", err
+	}
+	if err := os.Chdir(filepath.Join(scratch, rel)); err != nil {
+		return "", err
+	}
+
+	scratchRoot := filepath.Join(scratch, relRoot)
+	if env := manifest.Environment(); env != "" {
+		if _, err := manifest.SelectEnvironment(scratch, env); err != nil {
+			return "", err
+		}
+		git.ScopeRoot(scratchRoot)
+		defer func() {
+			git.ScopeRoot(root)
+			_, _ = manifest.SelectEnvironment(toplevel, env)
+		}()
+	}
+
+	for _, installation := range installations {
+		if _, err := p.doBuild(installation, false, true); err != nil {
+			return "", err
+		}
+	}
+	return scratchRoot, nil
+}
+
+// decryptContents decrypts files the working tree still has encrypted, and passes everything else through
+func decryptContents(contents []byte) ([]byte, error) {
+	if !bytes.HasPrefix(contents, prefix) {
+		return contents, nil
+	}
+
+	prov, err := crypto.Build()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Decrypt(prov, contents[len(prefix):])
+}
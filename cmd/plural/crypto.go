@@ -32,6 +32,10 @@ const Gitattributes = `/**/helm/**/values.yaml filter=plural-crypt diff=plural-c
 /**/terraform/**/main.tf* filter=plural-crypt diff=plural-crypt
 /**/manifest.yaml filter=plural-crypt diff=plural-crypt
 /**/output.yaml filter=plural-crypt diff=plural-crypt
+/**/.plural/history/* filter=plural-crypt diff=plural-crypt
+/**/.plural/values.base.yaml filter=plural-crypt diff=plural-crypt
+/**/.plural/main.base.tf filter=plural-crypt diff=plural-crypt
+/**/.plural/manifests.yaml filter=plural-crypt diff=plural-crypt
 /diffs/**/* filter=plural-crypt diff=plural-crypt
 context.yaml filter=plural-crypt diff=plural-crypt
 workspace.yaml filter=plural-crypt diff=plural-crypt
@@ -43,6 +47,8 @@ workspace.yaml* filter=plural-crypt diff=plural-crypt
 const Gitignore = `/**/.terraform
 /**/.terraform*
 /**/terraform.tfstate*
+/**/.plural/logs
+/.plural/snapshot
 /bin
 *~
 .idea
@@ -235,15 +241,8 @@ func CheckGitCrypt(c *cli.Context) error {
 }
 
 func cryptoInit(c *cli.Context) error {
-	encryptConfig := [][]string{
-		{"filter.plural-crypt.smudge", "plural crypto decrypt"},
-		{"filter.plural-crypt.clean", "plural crypto encrypt"},
-		{"filter.plural-crypt.required", "true"},
-		{"diff.plural-crypt.textconv", "plural crypto decrypt"},
-	}
-
 	utils.Highlight("Creating git encryption filters\n\n")
-	for _, conf := range encryptConfig {
+	for _, conf := range crypto.GitFilters {
 		if err := gitConfig(conf[0], conf[1]); err != nil {
 			return err
 		}
@@ -286,7 +285,7 @@ func (p *Plural) handleSetupKeys(c *cli.Context) error {
 }
 
 func handleUnlock(c *cli.Context) error {
-	repoRoot, err := git.Root()
+	repoRoot, err := git.Toplevel()
 	if err != nil {
 		return err
 	}
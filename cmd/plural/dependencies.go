@@ -1,8 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/wkspace"
 	"github.com/urfave/cli"
 )
@@ -11,6 +15,10 @@ func (p *Plural) topsort(c *cli.Context) error {
 	p.InitPluralClient()
 	installations, _ := p.GetInstallations()
 	repoName := c.Args().Get(0)
+	if format := c.String("format"); format != "" {
+		return p.dependencyGraph(format, repoName, installations)
+	}
+
 	sorted, err := wkspace.Dependencies(p.Client, repoName, installations)
 	if err != nil {
 		return err
@@ -21,3 +29,36 @@ func (p *Plural) topsort(c *cli.Context) error {
 	}
 	return nil
 }
+
+func (p *Plural) dependencyGraph(format, repo string, installations []*api.Installation) error {
+	graph, err := wkspace.BuildGraph(p.Client, installations)
+	if err != nil {
+		return err
+	}
+
+	if repo != "" {
+		if graph, err = graph.Subgraph(repo); err != nil {
+			return err
+		}
+	}
+
+	switch format {
+	case "dot":
+		fmt.Print(graph.Dot())
+	case "mermaid":
+		fmt.Print(graph.Mermaid())
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(graph); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported format %s, must be one of dot, mermaid or json", format)
+	}
+
+	if graph.Cycle != nil {
+		return fmt.Errorf("repos depend on each other in the cycle %s", utils.FormatCycle(graph.Cycle))
+	}
+	return nil
+}
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/application"
+	"github.com/pluralsh/plural/pkg/bundle"
 	"github.com/pluralsh/plural/pkg/diff"
 	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/kubernetes"
 	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/notify"
 	"github.com/pluralsh/plural/pkg/scaffold"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/errors"
@@ -20,6 +27,7 @@ import (
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 	"github.com/pluralsh/plural/pkg/wkspace"
 	"github.com/urfave/cli"
+	"golang.org/x/sync/errgroup"
 )
 
 func (p *Plural) getSortedInstallations(repo string) ([]*api.Installation, error) {
@@ -96,104 +104,210 @@ func diffed(_ *cli.Context) error {
 
 func (p *Plural) build(c *cli.Context) error {
 	p.InitPluralClient()
-	if err := CheckGitCrypt(c); err != nil {
-		return errors.ErrorWrap(errNoGit, "Failed to scan your repo for secrets to encrypt them")
-	}
-	changed, err := git.HasUpstreamChanges()
+	strategy, err := scaffold.ParseStrategy(c.String("strategy"))
 	if err != nil {
-		return errors.ErrorWrap(errNoGit, "Failed to get git information")
+		return err
 	}
+	scaffold.ValuesStrategy = strategy
 
-	force := c.Bool("force")
-	if !changed && !force {
-		return errors.ErrorWrap(errRemoteDiff, "Local Changes out of Sync")
+	if c.Bool("check") {
+		return p.checkBuild(c)
 	}
 
-	if c.IsSet("only") {
-		installation, err := p.GetInstallation(c.String("only"))
+	if err := CheckGitCrypt(c); err != nil {
+		return errors.ErrorWrap(errNoGit, "Failed to scan your repo for secrets to encrypt them")
+	}
+
+	force := c.Bool("force")
+	if c.GlobalBool("snapshot") {
+		// air-gapped builds can't reach the remote to compare against it
+		utils.Warn("building from a snapshot, skipping the check for upstream changes\n")
+	} else {
+		changed, err := git.HasUpstreamChanges()
 		if err != nil {
-			return err
-		} else if installation == nil {
-			return utils.HighlightError(fmt.Errorf("%s is not installed. Please install it with `plural bundle install`", c.String("only")))
+			return errors.ErrorWrap(errNoGit, "Failed to get git information")
+		}
+		if !changed && !force {
+			return errors.ErrorWrap(errRemoteDiff, "Local Changes out of Sync")
 		}
-
-		return p.doBuild(installation, force)
 	}
 
-	installations, err := p.getSortedInstallations("")
+	p.Client = wkspace.NewPackageCache(p.Client)
+	installations, err := p.buildInstallations(c.String("only"))
 	if err != nil {
 		return err
 	}
 
+	results := make([]*buildResult, 0, len(installations))
 	for _, installation := range installations {
-		if err := p.doBuild(installation, force); err != nil {
+		result, err := p.doBuild(installation, force, false)
+		if err != nil {
 			return err
 		}
+		results = append(results, result)
 	}
+
+	printBuildSummary(results)
 	return nil
 }
 
-func (p *Plural) doBuild(installation *api.Installation, force bool) error {
+// buildInstallations returns the installations to build in dependency order, or just the one named by only
+func (p *Plural) buildInstallations(only string) ([]*api.Installation, error) {
+	if only == "" {
+		return p.getSortedInstallations("")
+	}
+
+	installation, err := p.GetInstallation(only)
+	if err != nil {
+		return nil, err
+	} else if installation == nil {
+		return nil, utils.HighlightError(fmt.Errorf("%s is not installed. Please install it with `plural bundle install`", only))
+	}
+	return []*api.Installation{installation}, nil
+}
+
+// buildResult records whether a repo was rebuilt, and why
+type buildResult struct {
+	repo    string
+	reasons []string
+}
+
+// doBuild builds a repo if its inputs changed since its last build, or always when forced or checking
+func (p *Plural) doBuild(installation *api.Installation, force, check bool) (*buildResult, error) {
 	p.InitPluralClient()
 	repoName := installation.Repository.Name
-	fmt.Printf("Building workspace for %s\n", repoName)
+	result := &buildResult{repo: repoName}
 
 	if !wkspace.Configured(repoName) {
-		return fmt.Errorf("You have not locally configured %s but have it registered as an installation in our api, either delete it in app.plural.sh or install it locally via a bundle in `plural bundle list %s`", repoName, repoName)
+		return result, fmt.Errorf("You have not locally configured %s but have it registered as an installation in our api, either delete it in app.plural.sh or install it locally via a bundle in `plural bundle list %s`", repoName, repoName)
 	}
 
 	workspace, err := wkspace.New(p.Client, installation)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	if err := workspace.Prepare(); err != nil {
-		return err
+	repoRoot, err := git.Root()
+	if err != nil {
+		return result, err
 	}
 
 	build, err := scaffold.Scaffolds(workspace)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	err = build.Execute(workspace, force)
+	fingerprint, err := workspace.Fingerprint(repoRoot, version, string(scaffold.ValuesStrategy))
+	if err != nil {
+		return result, err
+	}
+
+	result.reasons = build.Stale(repoRoot, fingerprint)
+	if check {
+		result.reasons = append([]string{"--check"}, result.reasons...)
+	}
+	if force {
+		result.reasons = append([]string{"--force"}, result.reasons...)
+	}
+	if workspace.Links != nil && (len(workspace.Links.Helm) > 0 || len(workspace.Links.Terraform) > 0) {
+		result.reasons = append(result.reasons, "local links are configured")
+	}
+	if len(result.reasons) == 0 {
+		utils.Success("%s is up to date, skipping\n", repoName)
+		return result, nil
+	}
+
+	fmt.Printf("Building workspace for %s (%s)\n", repoName, strings.Join(result.reasons, ", "))
+	if err := workspace.Prepare(); err != nil {
+		return result, err
+	}
+
+	err = build.Execute(workspace, fingerprint, force)
 	if err == nil {
 		utils.Success("Finished building %s\n\n", repoName)
 	}
 
 	workspace.PrintLinks()
 
-	return err
+	return result, err
+}
+
+func printBuildSummary(results []*buildResult) {
+	rebuilt := 0
+	for _, result := range results {
+		if len(result.reasons) > 0 {
+			rebuilt++
+		}
+	}
+
+	utils.Highlight("\nRebuilt %d of %d %s:\n", rebuilt, len(results), utils.Pluralize("repo", "repos", len(results)))
+	for _, result := range results {
+		if len(result.reasons) == 0 {
+			fmt.Printf("  %-20s up to date\n", result.repo)
+			continue
+		}
+		fmt.Printf("  %-20s rebuilt: %s\n", result.repo, strings.Join(result.reasons, ", "))
+	}
 }
 
 func (p *Plural) validate(c *cli.Context) error {
 	p.InitPluralClient()
+	var installations []*api.Installation
 	if c.IsSet("only") {
 		installation, err := p.GetInstallation(c.String("only"))
 		if err != nil {
 			return err
 		}
-		return p.doValidate(installation)
+		installations = []*api.Installation{installation}
+	} else {
+		sorted, err := p.getSortedInstallations("")
+		if err != nil {
+			return err
+		}
+		installations = sorted
 	}
 
-	installations, err := p.getSortedInstallations("")
+	jsonOutput := outputFormat(c) == "json"
+	errs := make([]*bundle.ValidationError, 0)
+	for _, installation := range installations {
+		if !jsonOutput {
+			utils.Highlight("Validating repository %s\n", installation.Repository.Name)
+		}
+		if err := p.doValidate(installation); err != nil {
+			errs = append(errs, &bundle.ValidationError{Repo: installation.Repository.Name, Message: err.Error()})
+		}
+	}
+
+	contextErrs, err := p.validateContext(installations)
 	if err != nil {
 		return err
 	}
+	errs = append(errs, contextErrs...)
 
-	for _, installation := range installations {
-		if err := p.doValidate(installation); err != nil {
+	if jsonOutput {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(errs); err != nil {
 			return err
 		}
+	} else {
+		for _, err := range errs {
+			utils.Error("%s\n", err)
+		}
 	}
 
-	utils.Success("Workspace providers are properly configured!\n")
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d %s in the workspace", len(errs), utils.Pluralize("problem", "problems", len(errs)))
+	}
+
+	if !jsonOutput {
+		utils.Success("Workspace providers and context.yaml are properly configured!\n")
+	}
 	return nil
 }
 
 func (p *Plural) doValidate(installation *api.Installation) error {
 	p.InitPluralClient()
-	utils.Highlight("Validating repository %s\n", installation.Repository.Name)
 	workspace, err := wkspace.New(p.Client, installation)
 	if err != nil {
 		return err
@@ -202,7 +316,55 @@ func (p *Plural) doValidate(installation *api.Installation) error {
 	return workspace.Validate()
 }
 
-func (p *Plural) deploy(c *cli.Context) error {
+// validateContext checks context.yaml against the recipes of the bundles installed for the given
+// installations
+func (p *Plural) validateContext(installations []*api.Installation) ([]*bundle.ValidationError, error) {
+	ctx, err := manifest.ReadContext(manifest.ContextPath())
+	if err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		return []*bundle.ValidationError{}, nil
+	}
+
+	installed := make(map[string]bool)
+	for _, installation := range installations {
+		installed[installation.Repository.Name] = true
+	}
+
+	recipes, err := p.bundleRecipes(ctx.Bundles, installed)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]*bundle.ValidationError, 0)
+	for _, err := range bundle.ValidateContext(ctx, recipes) {
+		if installed[err.Repo] {
+			errs = append(errs, err)
+		}
+	}
+	return errs, nil
+}
+
+// bundleRecipes fetches the recipes of bundles, skipping those of repos that aren't in include when
+// it's set
+func (p *Plural) bundleRecipes(bundles []*manifest.Bundle, include map[string]bool) ([]*api.Recipe, error) {
+	recipes := make([]*api.Recipe, 0)
+	for _, b := range bundles {
+		if include != nil && !include[b.Repository] {
+			continue
+		}
+
+		recipe, err := p.GetRecipe(b.Repository, b.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bundle %s/%s: %w", b.Repository, b.Name, err)
+		}
+		recipes = append(recipes, recipe)
+	}
+	return recipes, nil
+}
+
+func (p *Plural) deploy(c *cli.Context) (err error) {
 	p.InitPluralClient()
 	verbose := c.Bool("verbose")
 	repoRoot, err := git.Root()
@@ -223,57 +385,170 @@ func (p *Plural) deploy(c *cli.Context) error {
 		}
 	}
 
-	fmt.Printf("Deploying applications [%s] in topological order\n\n", strings.Join(sorted, ", "))
-
 	ignoreConsole := c.Bool("ignore-console")
+	repos := make([]string, 0, len(sorted))
 	for _, repo := range sorted {
 		if ignoreConsole && (repo == "console" || repo == "bootstrap") {
 			continue
 		}
+		repos = append(repos, repo)
+	}
 
-		execution, err := executor.GetExecution(pathing.SanitizeFilepath(filepath.Join(repoRoot, repo)), "deploy")
-		if err != nil {
+	if c.Bool("dry-run") {
+		return planDeploy(repoRoot, repos)
+	}
+
+	n := notify.Start("deploy", repos)
+	defer func() { n.Finish(err) }()
+
+	layers, err := wkspace.TopSortLayers(repos)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := executor.NotifyContext(context.Background())
+	defer stop()
+
+	out := progressOutput(c)
+	fmt.Fprintf(out, "Deploying applications [%s] in topological order\n\n", strings.Join(repos, ", "))
+
+	for _, layer := range layers {
+		if err := deployLayer(ctx, repoRoot, layer, verbose, c.Int("parallelism"), out); err != nil {
+			if ctx.Err() != nil {
+				utils.Note("Deployment interrupted, rerun `plural deploy` to resume from the interrupted step\n")
+				return err
+			}
+			utils.Note("It looks like your deployment failed. This may be a transient issue and rerunning the `plural deploy` command may resolve it. Or, feel free to reach out to us on discord (https://discord.gg/bEBAMXV64s) or Intercom and we should be able to help you out\n")
 			return err
 		}
 
-		if err := execution.Execute(verbose); err != nil {
-			utils.Note("It looks like your deployment failed. This may be a transient issue and rerunning the `plural deploy` command may resolve it. Or, feel free to reach out to us on discord (https://discord.gg/bEBAMXV64s) or Intercom and we should be able to help you out\n")
+		for _, repo := range layer {
+			n.Current(repo)
+			installation, err := p.GetInstallation(repo)
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("silence") {
+				continue
+			}
+
+			if man, err := fetchManifest(repo); err == nil && man.Wait {
+				if kubeConf, err := kubernetes.KubeConfig(); err == nil {
+					fmt.Println("")
+					if err := application.Wait(kubeConf, repo); err != nil {
+						return err
+					}
+					fmt.Println("")
+				}
+			}
+
+			if err := scaffold.Notes(installation); err != nil {
+				return err
+			}
+		}
+	}
+
+	utils.HighlightTo(out, "\n==> Commit and push your changes to record your deployment\n\n")
+
+	if commit := commitMsg(c); commit != "" {
+		utils.HighlightTo(out, "Pushing upstream...\n")
+		return git.Sync(repoRoot, commit, c.Bool("force"))
+	}
+
+	return nil
+}
+
+// planDeploy reports which deploy steps would run for each repo without executing anything, exiting
+// non-zero when there is pending work so it can gate ci
+func planDeploy(repoRoot string, repos []string) error {
+	diffed, err := wkspace.DiffedRepos()
+	if err != nil {
+		return err
+	}
+	sort.Strings(diffed)
+
+	fmt.Printf("Repos with local changes: [%s]\n\n", strings.Join(diffed, ", "))
+	pending := 0
+	for _, repo := range repos {
+		execution, err := executor.GetExecution(pathing.SanitizeFilepath(filepath.Join(repoRoot, repo)), "deploy")
+		if err != nil {
 			return err
 		}
-		fmt.Printf("\n")
 
-		installation, err := p.GetInstallation(repo)
+		plans, err := execution.Plan()
 		if err != nil {
 			return err
 		}
 
-		if c.Bool("silence") {
-			continue
+		utils.Highlight("%s:\n", repo)
+		for _, plan := range plans {
+			if !plan.Stale {
+				utils.Success("  skip  %s (%s)\n", plan.Step.Name, plan.Reason())
+				continue
+			}
+
+			pending++
+			utils.Highlight("  run   %s (%s) ~> %s\n", plan.Step.Name, plan.Reason(), plan.Step.CommandLine())
+			for _, file := range plan.Changed {
+				fmt.Printf("          %s\n", file)
+			}
 		}
+		fmt.Println()
+	}
 
-		if man, err := fetchManifest(repo); err == nil && man.Wait {
-			if kubeConf, err := kubernetes.KubeConfig(); err == nil {
-				fmt.Println("")
-				if err := application.Wait(kubeConf, repo); err != nil {
-					return err
-				}
-				fmt.Println("")
+	if pending > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d steps would run on deploy", pending), exitPendingChanges)
+	}
+
+	utils.Success("Nothing to deploy\n")
+	return nil
+}
+
+// deployLayer executes the repos of a single dependency layer, running up to parallelism of them
+// at once.  Concurrent output is prefixed by repo, and the first failure cancels the remaining repos
+func deployLayer(ctx context.Context, repoRoot string, layer []string, verbose bool, parallelism int, out io.Writer) error {
+	if parallelism <= 1 || len(layer) == 1 {
+		for _, repo := range layer {
+			if err := deployRepo(ctx, repoRoot, repo, verbose, out); err != nil {
+				return err
 			}
+			fmt.Fprintf(out, "\n")
 		}
+		return nil
+	}
+
+	var lock sync.Mutex
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(parallelism)
+	for _, repo := range layer {
+		repo := repo
+		group.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 
-		if err := scaffold.Notes(installation); err != nil {
+			prefixed := executor.NewPrefixWriter(out, &lock, fmt.Sprintf("[%s] ", repo))
+			err := deployRepo(ctx, repoRoot, repo, verbose, prefixed)
+			if ferr := prefixed.Flush(); ferr != nil && err == nil {
+				err = ferr
+			}
 			return err
-		}
+		})
 	}
 
-	utils.Highlight("\n==> Commit and push your changes to record your deployment\n\n")
+	return group.Wait()
+}
 
-	if commit := commitMsg(c); commit != "" {
-		utils.Highlight("Pushing upstream...\n")
-		return git.Sync(repoRoot, commit, c.Bool("force"))
+func deployRepo(ctx context.Context, repoRoot, repo string, verbose bool, out io.Writer) error {
+	execution, err := executor.GetExecution(pathing.SanitizeFilepath(filepath.Join(repoRoot, repo)), "deploy")
+	if err != nil {
+		// repos in a layer deploy concurrently, so the error has to say which one failed
+		return &executor.StepError{Repo: repo, Err: err}
 	}
 
-	return nil
+	execution.Out = out
+	return execution.Execute(ctx, verbose)
 }
 
 func commitMsg(c *cli.Context) string {
@@ -292,7 +567,7 @@ func commitMsg(c *cli.Context) string {
 	return ""
 }
 
-func handleDiff(_ *cli.Context) error {
+func handleDiff(c *cli.Context) error {
 	repoRoot, err := git.Root()
 	if err != nil {
 		return err
@@ -303,21 +578,95 @@ func handleDiff(_ *cli.Context) error {
 		return err
 	}
 
-	fmt.Printf("Diffing applications [%s] in topological order\n\n", strings.Join(sorted, ", "))
+	// progress goes to stderr with a report, so the report can be piped straight into a pr comment
+	report := c.String("report")
+	out := progressOutput(c)
+	if report != "" {
+		out = os.Stderr
+	}
+
+	fmt.Fprintf(out, "Diffing applications [%s] in topological order\n\n", strings.Join(sorted, ", "))
+
+	summaries := []string{diff.TerraformSummary, diff.HelmSummary}
+	if c.Bool("offline") {
+		summaries = []string{diff.HelmSummary}
+	}
 
 	for _, repo := range sorted {
+		if err := diff.RemoveSummaries(repoRoot, repo, summaries...); err != nil {
+			return err
+		}
+
+		if c.Bool("offline") {
+			if err := diffOffline(repoRoot, repo, out); err != nil {
+				return err
+			}
+			continue
+		}
+
 		d, err := diff.GetDiff(pathing.SanitizeFilepath(filepath.Join(repoRoot, repo)), "diff")
 		if err != nil {
 			return err
 		}
 
+		d.Out = out
 		if err := d.Execute(); err != nil {
 			return err
 		}
 
-		fmt.Printf("\n")
+		fmt.Fprintf(out, "\n")
 	}
-	return nil
+
+	if report == "" {
+		return nil
+	}
+
+	summary, err := diff.LoadReport(repoRoot, sorted, summaries...)
+	if err != nil {
+		return err
+	}
+	return summary.Render(stdout, report)
+}
+
+// diffOffline diffs the repo's chart against the manifests rendered at its last deploy, replacing
+// any previous helm diff for the repo.  Terraform can't be diffed offline, so its diff is left be
+func diffOffline(root, repo string, out io.Writer) error {
+	if !utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))) {
+		return nil
+	}
+
+	if !utils.Exists(diff.SnapshotPath(root, repo)) {
+		utils.Warn("%s has no rendered manifests from a previous deploy, skipping\n", repo)
+		return nil
+	}
+
+	dir := pathing.SanitizeFilepath(filepath.Join(root, "diffs", repo))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Remove(diff.SummaryPath(root, repo, "helm")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	changes, err := wkspace.OfflineHelmDiff(root, repo)
+	if err != nil {
+		return err
+	}
+
+	utils.HighlightTo(out, "%s: ", repo)
+	if len(changes) == 0 {
+		utils.SuccessTo(out, "no changes since the last deploy\n")
+	} else {
+		fmt.Fprintf(out, "%d objects differ from the last deploy\n", len(changes))
+		for _, change := range changes {
+			fmt.Fprintf(out, "  %-8s %s %s/%s", change.Action, change.Kind, change.Namespace, change.Name)
+			if len(change.Fields) > 0 {
+				fmt.Fprintf(out, " (%s)", strings.Join(change.Fields, ", "))
+			}
+			fmt.Fprintln(out)
+		}
+	}
+	return diff.WriteSummary(diff.SummaryPath(root, repo, diff.HelmSummary), changes)
 }
 
 func (p *Plural) bounce(c *cli.Context) error {
@@ -333,7 +682,12 @@ func (p *Plural) bounce(c *cli.Context) error {
 		if err != nil {
 			return err
 		}
-		return p.doBounce(repoRoot, installation)
+
+		n := notify.Start("bounce", []string{repoName})
+		n.Current(repoName)
+		err = p.doBounce(repoRoot, installation)
+		n.Finish(err)
+		return err
 	}
 
 	installations, err := p.getSortedInstallations(repoName)
@@ -341,14 +695,26 @@ func (p *Plural) bounce(c *cli.Context) error {
 		return err
 	}
 
+	n := notify.Start("bounce", installationNames(installations))
 	for _, installation := range installations {
+		n.Current(installation.Repository.Name)
 		if err := p.doBounce(repoRoot, installation); err != nil {
+			n.Finish(err)
 			return err
 		}
 	}
+	n.Finish(nil)
 	return nil
 }
 
+func installationNames(installations []*api.Installation) []string {
+	names := make([]string, 0, len(installations))
+	for _, installation := range installations {
+		names = append(names, installation.Repository.Name)
+	}
+	return names
+}
+
 func (p *Plural) doBounce(repoRoot string, installation *api.Installation) error {
 	p.InitPluralClient()
 	repoName := installation.Repository.Name
@@ -390,7 +756,11 @@ func (p *Plural) destroy(c *cli.Context) error {
 			return err
 		}
 
-		return p.doDestroy(repoRoot, installation)
+		n := notify.Start("destroy", []string{repoName})
+		n.Current(repoName)
+		err = p.doDestroy(repoRoot, installation)
+		n.Finish(err)
+		return err
 	}
 
 	installations, err := p.getSortedInstallations(repoName)
@@ -400,20 +770,27 @@ func (p *Plural) destroy(c *cli.Context) error {
 
 	from := c.String("from")
 	started := from == ""
+	destroying := []*api.Installation{}
 	for i := len(installations) - 1; i >= 0; i-- {
 		installation := installations[i]
 		if installation.Repository.Name == from {
 			started = true
 		}
 
-		if !started {
-			continue
+		if started {
+			destroying = append(destroying, installation)
 		}
+	}
 
+	n := notify.Start("destroy", installationNames(destroying))
+	for _, installation := range destroying {
+		n.Current(installation.Repository.Name)
 		if err := p.doDestroy(repoRoot, installation); err != nil {
+			n.Finish(err)
 			return err
 		}
 	}
+	n.Finish(nil)
 
 	man, _ := manifest.FetchProject()
 	if err := p.DeleteEabCredential(man.Cluster, man.Provider); err != nil {
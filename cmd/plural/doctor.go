@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/pluralsh/plural/pkg/doctor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// runDoctor diagnoses the local tools, repo, credentials and cluster access plural depends on, failing
+// if any check does
+func runDoctor(c *cli.Context) error {
+	format := outputFormat(c)
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported output format %s, must be one of text or json", format)
+	}
+
+	results := doctor.Diagnose()
+	if err := printDiagnosis(format, results); err != nil {
+		return err
+	}
+
+	summary := doctor.Summarize(results)
+	if failed := summary[doctor.StatusFail]; failed > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d checks failed", failed, len(results)), 1)
+	}
+	if format == "text" {
+		utils.Success("%d checks passed with %d %s\n", summary[doctor.StatusPass], summary[doctor.StatusWarn], utils.Pluralize("warning", "warnings", summary[doctor.StatusWarn]))
+	}
+	return nil
+}
+
+func printDiagnosis(format string, results []*doctor.Result) error {
+	if format == "json" {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	colors := map[doctor.Status]*color.Color{
+		doctor.StatusPass: color.New(color.FgGreen, color.Bold),
+		doctor.StatusWarn: color.New(color.FgYellow, color.Bold),
+		doctor.StatusFail: color.New(color.FgRed, color.Bold),
+	}
+	for _, res := range results {
+		colors[res.Status].Printf("[%s] ", res.Status)
+		fmt.Printf("%s: %s\n", res.Check, res.Message)
+		if res.Hint != "" {
+			fmt.Printf("       %s\n", res.Hint)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/format"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+// drift checks every installed repo, not just modified ones, against what's running in the cluster
+func (p *Plural) drift(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos := []string(c.Args())
+	if len(repos) == 0 {
+		if repos, err = p.allSortedRepos(); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := executor.NotifyContext(context.Background())
+	defer stop()
+
+	prov, err := provider.GetProvider()
+	if err != nil {
+		return err
+	}
+	kubeErr := prov.KubeConfig()
+
+	drifts := make([]*wkspace.RepoDrift, 0, len(repos))
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		utils.Warn("checking %s for drift\n", repo)
+		minimal, err := wkspace.Minimal(repo)
+		if err != nil {
+			return err
+		}
+
+		var helm *wkspace.DriftResult
+		switch {
+		case kubeErr == nil:
+			helm = minimal.HelmDrift(ctx, root)
+		case utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))):
+			helm = &wkspace.DriftResult{Tool: "helm", Status: wkspace.DriftError, Error: fmt.Sprintf("could not set up kubeconfig: %s", kubeErr)}
+		}
+		drifts = append(drifts, wkspace.NewRepoDrift(repo, minimal.TerraformDrift(ctx, root), helm))
+	}
+
+	if err := printDrift(outputFormat(c), drifts); err != nil {
+		return err
+	}
+
+	drifted, errored := 0, 0
+	for _, drift := range drifts {
+		switch drift.Status {
+		case wkspace.DriftDrifted:
+			drifted++
+		case wkspace.DriftError:
+			errored++
+		}
+	}
+
+	if errored > 0 {
+		return fmt.Errorf("could not check %d of %d repos for drift", errored, len(drifts))
+	}
+	if drifted > 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d repos have drifted", drifted, len(drifts)), exitDriftDetected)
+	}
+
+	utils.Success("all %d repos are in sync\n", len(drifts))
+	return nil
+}
+
+func printDrift(typ string, drifts []*wkspace.RepoDrift) error {
+	if typ == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(drifts)
+	}
+
+	formatter := format.New(format.FormatType(typ))
+	formatter.Header([]string{"Repo", "Status", "Terraform", "Helm", "Error"})
+	for _, drift := range drifts {
+		errs := ""
+		line := []string{drift.Repo, string(drift.Status)}
+		for _, tool := range []string{"terraform", "helm"} {
+			res := drift.Result(tool)
+			if res == nil {
+				line = append(line, "-")
+				continue
+			}
+
+			line = append(line, res.String())
+			if res.Error != "" {
+				errs = fmt.Sprintf("%s: %s", tool, res.Error)
+			}
+		}
+
+		if err := formatter.Write(append(line, errs)); err != nil {
+			return err
+		}
+	}
+	return formatter.Flush()
+}
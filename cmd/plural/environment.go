@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+// setupEnvironment switches to the workspace of the environment selected with --env or PLURAL_ENV.
+// The environment's directory stands in for the root of the repo from then on, so builds generate
+// into it and deploys run from it, while workspace.yaml and context.yaml resolve to the base files
+// with the environment's overlays merged in
+func setupEnvironment(c *cli.Context) error {
+	name := c.GlobalString("env")
+	if name == "" {
+		return nil
+	}
+
+	root, err := git.Toplevel()
+	if err != nil {
+		return err
+	}
+
+	dir, err := manifest.SelectEnvironment(root, name)
+	if err != nil {
+		return err
+	}
+
+	git.ScopeRoot(dir)
+	return os.Chdir(dir)
+}
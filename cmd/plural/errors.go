@@ -8,3 +8,10 @@ var (
 	errNoGit      = fmt.Errorf("Could not compare current workspace to origin. Do you have an `origin` remote configured, or does your repo not have an initial commit?")
 	errRemoteDiff = fmt.Errorf("Your local workspace is not in sync with remote. Either `git pull` recent changes or `git push` any missed changes.")
 )
+
+const (
+	// exitPendingChanges is the exit code for commands that detect work still to be applied
+	exitPendingChanges = 2
+	// exitDriftDetected is the exit code when the cluster no longer matches the workspace
+	exitDriftDetected = 3
+)
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/format"
+	"github.com/pluralsh/plural/pkg/history"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+const historyDateFormat = "2006-01-02"
+
+func handleHistory(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repos := []string{c.Args().First()}
+	if repos[0] == "" {
+		if repos, err = historyRepos(root); err != nil {
+			return err
+		}
+	}
+
+	since, err := parseHistoryTime(c.String("since"), false)
+	if err != nil {
+		return err
+	}
+	until, err := parseHistoryTime(c.String("until"), true)
+	if err != nil {
+		return err
+	}
+
+	filter := &history.Filter{Status: c.String("status"), Since: since, Until: until}
+	entries := make([]*history.Entry, 0)
+	for _, repo := range repos {
+		repoEntries, err := history.Read(history.Dir(root, repo))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, filter.Apply(repoEntries)...)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedAt.After(entries[j].StartedAt)
+	})
+
+	typ := outputFormat(c)
+	if typ == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	formatter := format.New(format.FormatType(typ))
+	formatter.Header([]string{"Repo", "Started", "Status", "User", "Commit", "Duration", "Failed Step"})
+	for _, entry := range entries {
+		commit := entry.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+
+		failed := ""
+		for _, step := range entry.Steps {
+			if step.Status == history.StatusFailed {
+				failed = fmt.Sprintf("%s (exit %d)", step.Name, step.ExitCode)
+			}
+		}
+
+		duration := time.Duration(entry.Duration * float64(time.Second)).Round(time.Second).String()
+		line := []string{entry.Repo, entry.StartedAt.Local().Format(time.RFC822), entry.Status, entry.User, commit, duration, failed}
+		if err := formatter.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return formatter.Flush()
+}
+
+// historyRepos finds every repo in the workspace with a deploy journal
+func historyRepos(root string) ([]string, error) {
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]string, 0)
+	for _, f := range files {
+		if f.IsDir() && utils.Exists(history.Dir(root, f.Name())) {
+			repos = append(repos, f.Name())
+		}
+	}
+	return repos, nil
+}
+
+// parseHistoryTime accepts either a date or a duration into the past, like 72h.  Dates are
+// inclusive, so with endOfDay they resolve to the end of that day
+func parseHistoryTime(val string, endOfDay bool) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+
+	if dur, err := time.ParseDuration(val); err == nil {
+		return time.Now().Add(-dur), nil
+	}
+
+	t, err := time.ParseInLocation(historyDateFormat, val, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("%s is neither a date formatted like %s nor a duration like 72h", val, historyDateFormat)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pluralsh/plural/pkg/lock"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func lockCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:   "status",
+			Usage:  "shows who currently holds the workspace lock",
+			Action: handleLockStatus,
+		},
+		{
+			Name:  "break",
+			Usage: "forcibly removes the workspace lock, eg after a crashed deploy",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "shared",
+					Usage: "also remove the shared lock pushed to origin",
+				},
+			},
+			Action: handleLockBreak,
+		},
+	}
+}
+
+func handleLockStatus(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	local, err := lock.Read(lock.LocalPath(root))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	printLock("local", local)
+
+	if lock.SharedEnabled() {
+		shared, err := lock.ReadShared(root)
+		if err != nil {
+			return err
+		}
+		printLock("shared", shared)
+	}
+	return nil
+}
+
+func printLock(kind string, l *lock.Lock) {
+	switch {
+	case l == nil:
+		utils.Success("%s lock: unlocked\n", kind)
+	case l.Stale():
+		utils.Warn("%s lock: stale, held by %s\n", kind, l)
+	default:
+		utils.Highlight("%s lock: ", kind)
+		fmt.Printf("held by %s\n", l)
+	}
+}
+
+func handleLockBreak(c *cli.Context) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	shared := c.Bool("shared")
+	holder, err := lock.Read(lock.LocalPath(root))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if holder == nil && shared {
+		if holder, err = lock.ReadShared(root); err != nil {
+			return err
+		}
+	}
+
+	if holder == nil {
+		utils.Success("workspace is not locked\n")
+		return nil
+	}
+
+	if !holder.Stale() && !confirm(fmt.Sprintf("The lock is still held by %s, are you sure you want to break it?", holder)) {
+		return nil
+	}
+
+	if err := lock.Break(root, shared); err != nil {
+		return err
+	}
+
+	utils.Success("workspace lock broken\n")
+	return nil
+}
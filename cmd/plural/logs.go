@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/logs"
+	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/urfave/cli"
 )
 
@@ -23,6 +28,22 @@ func (p *Plural) logsCommands() []cli.Command {
 			ArgsUsage: "REPO NAME",
 			Action:    requireArgs(p.handleLogTail, []string{"REPO", "NAME"}),
 		},
+		{
+			Name:      "step",
+			Usage:     "prints the full output of the latest run of a deploy step",
+			ArgsUsage: "REPO STEP",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "follow, f",
+					Usage: "keep printing output until the step finishes",
+				},
+				cli.BoolFlag{
+					Name:  "list",
+					Usage: "list the retained logs for the step instead",
+				},
+			},
+			Action: requireArgs(handleStepLogs, []string{"REPO", "STEP"}),
+		},
 	}
 }
 
@@ -60,3 +81,43 @@ func (p *Plural) handleLogTail(c *cli.Context) error {
 	}
 	return logs.Tail(p.Kube, conf.Namespace(repo), name)
 }
+
+func handleStepLogs(c *cli.Context) error {
+	repo := c.Args().Get(0)
+	step := c.Args().Get(1)
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	files, err := executor.StepLogs(executor.LogDir(root, repo), step)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no logs found for step %s in %s", step, repo)
+	}
+
+	if c.Bool("list") {
+		for i := len(files) - 1; i >= 0; i-- {
+			fmt.Println(files[i])
+		}
+		return nil
+	}
+
+	latest := files[len(files)-1]
+	if c.Bool("follow") {
+		ctx, stop := executor.NotifyContext(context.Background())
+		defer stop()
+		return executor.FollowLog(ctx, latest, os.Stdout)
+	}
+
+	f, err := os.Open(latest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(os.Stdout, f)
+	return err
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"os"
@@ -9,6 +11,7 @@ import (
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/kubernetes"
 
 	"github.com/fatih/color"
@@ -62,10 +65,19 @@ func (p *Plural) getCommands() []cli.Command {
 				},
 				cli.BoolFlag{
 					Name:  "force",
-					Usage: "force workspace to build even if remote is out of sync",
+					Usage: "force workspace to build even if remote is out of sync, and rebuild repos whose inputs haven't changed",
+				},
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "rebuild into a scratch copy of the workspace and fail if anything would change, without touching the repo",
+				},
+				cli.StringFlag{
+					Name:  "strategy",
+					Usage: "how to resolve edits to values.yaml or main.tf conflicting with changes to what plural generates, ours keeps the edit and theirs takes the generated value",
+					Value: "theirs",
 				},
 			},
-			Action: tracked(owned(p.build), "cli.build"),
+			Action: tracked(owned(locked(streamed(p.build))), "cli.build"),
 		},
 		{
 			Name:      "deploy",
@@ -97,15 +109,46 @@ func (p *Plural) getCommands() []cli.Command {
 					Name:  "force",
 					Usage: "use force push when pushing to git",
 				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "show which steps would run and why, without deploying anything",
+				},
+				cli.IntFlag{
+					Name:  "parallelism",
+					Usage: "number of independent repos to deploy at once",
+					Value: 1,
+				},
 			},
-			Action: tracked(owned(rooted(p.deploy)), "cli.deploy"),
+			Action: tracked(owned(rooted(locked(streamed(p.deploy)))), "cli.deploy"),
 		},
 		{
 			Name:      "diff",
 			Aliases:   []string{"df"},
 			Usage:     "diffs the state of the current workspace with the deployed version and dumps results to diffs/",
 			ArgsUsage: "WKSPACE",
-			Action:    handleDiff,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "report",
+					Usage: "also print a summary of all changes with secrets masked, as md or json",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "diff helm charts against the manifests rendered at their last deploy, without cluster access",
+				},
+			},
+			Action: streamed(handleDiff),
+		},
+		{
+			Name:      "drift",
+			Usage:     "checks every installed repo for drift between the workspace and the cluster, exiting with code 3 if any has drifted",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print the report in, table, csv or json, defaults to --output",
+				},
+			},
+			Action: tracked(owned(rooted(p.drift)), "cli.drift"),
 		},
 		{
 			Name:     "create",
@@ -149,20 +192,31 @@ func (p *Plural) getCommands() []cli.Command {
 		{
 			Name:    "validate",
 			Aliases: []string{"v"},
-			Usage:   "validates your workspace",
+			Usage:   "validates your workspace, checking provider support and context.yaml against the configuration of your bundles, domains and buckets are only checked for uniqueness within context.yaml",
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "only",
 					Usage: "repository to (re)build",
 				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print problems in, text or json, defaults to --output",
+				},
 			},
 			Action:   p.validate,
 			Category: "Workspace",
 		},
 		{
-			Name:     "topsort",
-			Aliases:  []string{"d"},
-			Usage:    "renders a dependency-inferred topological sort of the installations in a workspace",
+			Name:      "topsort",
+			Aliases:   []string{"d"},
+			Usage:     "renders a dependency-inferred topological sort of the installations in a workspace",
+			ArgsUsage: "[REPO]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "render the dependency graph instead, as dot, mermaid or json, reporting any cycle in it",
+				},
+			},
 			Action:   p.topsort,
 			Category: "Workspace",
 		},
@@ -171,7 +225,7 @@ func (p *Plural) getCommands() []cli.Command {
 			Aliases:   []string{"b"},
 			Usage:     "redeploys the charts in a workspace",
 			ArgsUsage: "WKSPACE",
-			Action:    owned(p.bounce),
+			Action:    owned(locked(p.bounce)),
 		},
 		{
 			Name:      "destroy",
@@ -192,7 +246,7 @@ func (p *Plural) getCommands() []cli.Command {
 					Usage: "use force push when pushing to git",
 				},
 			},
-			Action: tracked(owned(p.destroy), "cli.destroy"),
+			Action: tracked(owned(locked(p.destroy)), "cli.destroy"),
 		},
 		{
 			Name:  "init",
@@ -316,6 +370,79 @@ func (p *Plural) getCommands() []cli.Command {
 			Subcommands: outputCommands(),
 			Category:    "Workspace",
 		},
+		{
+			Name:        "lock",
+			Usage:       "inspect and break the workspace deploy lock",
+			Subcommands: lockCommands(),
+			Category:    "Workspace",
+		},
+		{
+			Name:  "snapshot",
+			Usage: "captures everything builds need from the plural api, for use with --snapshot",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:   "dir",
+					Usage:  "`DIR` to write the snapshot to, by default .plural/snapshot in the repo",
+					EnvVar: "PLURAL_SNAPSHOT_DIR",
+				},
+			},
+			Action:   tracked(owned(rooted(p.snapshot)), "cli.snapshot"),
+			Category: "Workspace",
+		},
+		{
+			Name:      "promote",
+			Usage:     "promotes context.yaml configuration and pinned chart versions from one environment to another, showing what changes first",
+			ArgsUsage: "[REPO...]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "from",
+					Usage: "environment to promote configuration from",
+				},
+				cli.StringFlag{
+					Name:  "to",
+					Usage: "environment to promote configuration to",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "only show what would be promoted",
+				},
+				cli.StringFlag{
+					Name:  "branch",
+					Usage: "create `BRANCH` and commit and push the promotion to it for review",
+				},
+			},
+			Action:   p.promote,
+			Category: "Workspace",
+		},
+		{
+			Name:      "import-release",
+			Usage:     "adopts an existing helm release and its terraform resources into a built repo",
+			ArgsUsage: "REPO",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "release",
+					Usage: "name of the existing helm release, defaults to the repo's name",
+				},
+				cli.StringFlag{
+					Name:  "namespace",
+					Usage: "namespace of the existing helm release, defaults to the repo's namespace",
+				},
+				cli.StringFlag{
+					Name:  "chart",
+					Usage: "`CHART` of the repo the release's values belong under",
+				},
+				cli.StringFlag{
+					Name:  "mapping",
+					Usage: "yaml `FILE` mapping the release's values to context.yaml and listing terraform resources to import",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "only show what would be imported",
+				},
+			},
+			Action:   owned(rooted(locked(requireArgs(p.importRelease, []string{"REPO"})))),
+			Category: "Workspace",
+		},
 		{
 			Name:        "logs",
 			Usage:       "Commands for tailing logs for specific apps",
@@ -343,6 +470,18 @@ func (p *Plural) getCommands() []cli.Command {
 			Subcommands: p.opsCommands(),
 			Category:    "Debugging",
 		},
+		{
+			Name:  "doctor",
+			Usage: "diagnoses the tools, repo, credentials and cluster access plural needs, with hints to fix them",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "report `FORMAT`, text or json, defaults to --output",
+				},
+			},
+			Action:   runDoctor,
+			Category: "Debugging",
+		},
 		{
 			Name:        "utils",
 			Usage:       "useful plural utilities",
@@ -368,6 +507,31 @@ func (p *Plural) getCommands() []cli.Command {
 			Action:   p.buildContext,
 			Category: "Workspace",
 		},
+		{
+			Name:      "history",
+			Usage:     "shows the deploy history of a repo, or of every repo in the workspace",
+			ArgsUsage: "[REPO]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "status",
+					Usage: "only show deploys with this status, eg succeeded, failed or cancelled",
+				},
+				cli.StringFlag{
+					Name:  "since",
+					Usage: "only show deploys since this date (2006-01-02) or duration ago (72h)",
+				},
+				cli.StringFlag{
+					Name:  "until",
+					Usage: "only show deploys until this date (2006-01-02) or duration ago (72h)",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "format to print the history out, table, csv or json, defaults to --output",
+				},
+			},
+			Action:   handleHistory,
+			Category: "Workspace",
+		},
 		{
 			Name:     "changed",
 			Usage:    "shows repos with pending changes",
@@ -412,6 +576,87 @@ func globalFlags() []cli.Flag {
 			EnvVar:      "PLURAL_ENCRYPTION_KEY_FILE",
 			Destination: &crypto.EncryptionKeyFile,
 		},
+		cli.StringFlag{
+			Name:   "output",
+			Usage:  "deploy, diff and build progress `FORMAT`, text or json",
+			EnvVar: "PLURAL_OUTPUT",
+			Value:  "text",
+		},
+		cli.StringFlag{
+			Name:   "env",
+			Usage:  "work in the environment `NAME`, with its own workspace under environments/ and overlays of workspace.yaml and context.yaml",
+			EnvVar: "PLURAL_ENV",
+		},
+		cli.BoolFlag{
+			Name:   "snapshot",
+			Usage:  "serve api calls and package downloads from a snapshot taken with the snapshot command, for air-gapped builds",
+			EnvVar: "PLURAL_SNAPSHOT",
+		},
+	}
+}
+
+func setup(c *cli.Context) error {
+	if err := setupOutput(c); err != nil {
+		return err
+	}
+	if err := setupEnvironment(c); err != nil {
+		return err
+	}
+	return setupSnapshot(c)
+}
+
+// stdout is where machine-readable output goes, even once --output json moves everything else to stderr
+var stdout io.Writer = os.Stdout
+
+// outputFormat is the format of a command's --format flag, defaulting to the global --output
+func outputFormat(c *cli.Context) string {
+	if format := c.String("format"); format != "" {
+		return format
+	}
+	return c.GlobalString("output")
+}
+
+// setupOutput checks the --output format, the commands streaming events set themselves up with streamed
+func setupOutput(c *cli.Context) error {
+	switch format := c.GlobalString("output"); format {
+	case "text", "json":
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %s, must be one of text or json", format)
+	}
+}
+
+// streamed runs a command that reports its steps as a json event stream on stdout with --output json.
+// Its output is written to progressOutput, and anything printed straight to stdout is moved to stderr
+// until it returns, so the stream stays machine-readable
+func streamed(fn func(*cli.Context) error) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.GlobalString("output") != "json" {
+			return fn(c)
+		}
+
+		executor.EnableEvents(stdout)
+		defer executor.DisableEvents()
+		defer redirectStdout()()
+		return fn(c)
+	}
+}
+
+// progressOutput is where a command's output for people goes, stderr when stdout is reserved for
+// machine-readable output
+func progressOutput(c *cli.Context) io.Writer {
+	if c.GlobalString("output") == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// redirectStdout moves everything printed to stdout to stderr, returning a func that restores it
+func redirectStdout() func() {
+	out, colorOut := os.Stdout, color.Output
+	os.Stdout, color.Output = os.Stderr, os.Stderr
+	return func() {
+		os.Stdout, color.Output = out, colorOut
 	}
 }
 
@@ -421,6 +666,7 @@ func CreateNewApp(plural *Plural) *cli.App {
 	app.Usage = "Tooling to manage your installed plural applications"
 	app.EnableBashCompletion = true
 	app.Flags = globalFlags()
+	app.Before = setup
 	app.Commands = plural.getCommands()
 	links := linkCommands()
 	app.Commands = append(app.Commands, links...)
@@ -19,6 +19,7 @@ COMMANDS:
    build, b         builds your workspace
    deploy, d        Deploys the current workspace. This command will first sniff out git diffs in workspaces, topsort them, then apply all changes.
    diff, df         diffs the state of the current workspace with the deployed version and dumps results to diffs/
+   drift            checks every installed repo for drift between the workspace and the cluster, exiting with code 3 if any has drifted
    bounce, b        redeploys the charts in a workspace
    destroy, b       iterates through all installations in reverse topological order, deleting helm installations and terraform
    init             initializes plural within a git repo
@@ -35,12 +36,13 @@ COMMANDS:
      api    inspect the forge api
 
    Debugging:
-     watch  watches applications until they become ready
-     wait   waits on applications until they become ready
-     info   generates a console dashboard for the namespace of this repo
-     proxy  proxies into running processes in your cluster
-     logs   Commands for tailing logs for specific apps
-     ops    Commands for simplifying cluster operations
+     watch   watches applications until they become ready
+     wait    waits on applications until they become ready
+     info    generates a console dashboard for the namespace of this repo
+     proxy   proxies into running processes in your cluster
+     logs    Commands for tailing logs for specific apps
+     ops     Commands for simplifying cluster operations
+     doctor  diagnoses the tools, repo, credentials and cluster access plural needs, with hints to fix them
 
    Miscellaneous:
      utils  useful plural utilities
@@ -66,18 +68,26 @@ COMMANDS:
      repair  commits any new encrypted changes in your local workspace automatically
 
    Workspace:
-     validate, v         validates your workspace
+     validate, v         validates your workspace, checking provider support and context.yaml against the configuration of your bundles, domains and buckets are only checked for uniqueness within context.yaml
      topsort, d          renders a dependency-inferred topological sort of the installations in a workspace
      serve               launch the server
      shell               manages your cloud shell
      workspace, wkspace  Commands for managing installations in your workspace
      output              Commands for generating outputs from supported tools
+     lock                inspect and break the workspace deploy lock
+     snapshot            captures everything builds need from the plural api, for use with --snapshot
+     promote             promotes context.yaml configuration and pinned chart versions from one environment to another, showing what changes first
+     import-release      adopts an existing helm release and its terraform resources into a built repo
      build-context       creates a fresh context.yaml for legacy repos
+     history             shows the deploy history of a repo, or of every repo in the workspace
      changed             shows repos with pending changes
 
 GLOBAL OPTIONS:
    --profile-file FILE         configure your config.yml profile FILE [$PLURAL_PROFILE_FILE]
    --encryption-key-file FILE  configure your encryption key FILE [$PLURAL_ENCRYPTION_KEY_FILE]
+   --output FORMAT             deploy, diff and build progress FORMAT, text or json (default: "text") [$PLURAL_OUTPUT]
+   --env NAME                  work in the environment NAME, with its own workspace under environments/ and overlays of workspace.yaml and context.yaml [$PLURAL_ENV]
+   --snapshot                  serve api calls and package downloads from a snapshot taken with the snapshot command, for air-gapped builds [$PLURAL_SNAPSHOT]
    --help, -h                  show help
 `
 
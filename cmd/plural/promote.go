@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/bundle"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// promote copies configuration from one environment's context.yaml overlay to another's, and pins the
+// chart versions of the source in the target, after showing what would change.  Environment specific
+// values, like domains and buckets, are never promoted
+func (p *Plural) promote(c *cli.Context) error {
+	from, to := c.String("from"), c.String("to")
+	if from == "" || to == "" {
+		return fmt.Errorf("both --from and --to environments are required")
+	}
+	if from == to {
+		return fmt.Errorf("can't promote %s to itself", from)
+	}
+
+	root, err := git.Toplevel()
+	if err != nil {
+		return err
+	}
+
+	source, err := manifest.ReadEnvironmentContext(root, from)
+	if err != nil {
+		return err
+	}
+	target, err := manifest.ReadEnvironmentContext(root, to)
+	if err != nil {
+		return err
+	}
+
+	repos := c.Args()
+	promotions := manifest.ContextPromotions(source, target, repos, p.environmentSpecific(source, target))
+	versions, err := manifest.VersionPromotions(manifest.EnvironmentDir(root, from), manifest.EnvironmentDir(root, to), repos)
+	if err != nil {
+		return err
+	}
+
+	promoted, err := printPromotions(to, target, promotions)
+	if err != nil {
+		return err
+	}
+	pinned := printVersionPromotions(from, to, versions)
+
+	if promoted == 0 && pinned == 0 {
+		utils.Success("%s has no configuration or chart versions to promote to %s\n", from, to)
+		return nil
+	}
+	summary := promotionSummary(promoted, pinned)
+	if c.Bool("dry-run") || !confirm(fmt.Sprintf("Promote %s from %s to %s?", summary, from, to)) {
+		return nil
+	}
+
+	if branch := c.String("branch"); branch != "" {
+		if err := git.CheckoutBranch(root, branch); err != nil {
+			return err
+		}
+	}
+
+	paths := []string{}
+	if promoted > 0 {
+		target.Promote(promotions)
+		if err := manifest.WriteEnvironmentContext(root, to, target); err != nil {
+			return err
+		}
+		paths = append(paths, filepath.Join(manifest.EnvironmentDir(root, to), "context.yaml"))
+	}
+	pins, err := pinVersions(manifest.EnvironmentDir(root, to), versions)
+	if err != nil {
+		return err
+	}
+	utils.Success("promoted %s from %s to %s\n", summary, from, to)
+
+	if c.String("branch") != "" {
+		msg := fmt.Sprintf("promote %s to %s", from, to)
+		if len(repos) > 0 {
+			msg = fmt.Sprintf("%s for %s", msg, strings.Join(repos, ", "))
+		}
+		// only the promoted files are committed, so unrelated local changes stay out of the review
+		return git.CommitPaths(root, msg, append(paths, pins...)...)
+	}
+	return nil
+}
+
+// pinVersions pins the promoted chart versions in the manifests and helm charts the target environment
+// last built, returning the files it rewrote
+func pinVersions(dir string, versions []*manifest.VersionPromotion) ([]string, error) {
+	paths, err := manifest.PromoteVersions(dir, versions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		if version.From == "" {
+			continue
+		}
+		path, err := scaffold.PinChartVersion(dir, version.Repo, version.Chart, version.To)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+func promotionSummary(values, versions int) string {
+	parts := make([]string, 0, 2)
+	if values > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", values, utils.Pluralize("value", "values", values)))
+	}
+	if versions > 0 {
+		parts = append(parts, fmt.Sprintf("%d chart %s", versions, utils.Pluralize("version", "versions", versions)))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// environmentSpecific finds the domain and bucket configuration of the bundles installed in either
+// environment.  Failing to fetch them only warns, since the domains and buckets registered in each
+// context.yaml are still recognized
+func (p *Plural) environmentSpecific(source, target *manifest.Context) map[string]map[string]bool {
+	p.InitPluralClient()
+	bundles := make([]*manifest.Bundle, 0)
+	seen := make(map[manifest.Bundle]bool)
+	for _, b := range append(source.Bundles, target.Bundles...) {
+		if !seen[*b] {
+			seen[*b] = true
+			bundles = append(bundles, b)
+		}
+	}
+
+	recipes, err := p.bundleRecipes(bundles, nil)
+	if err != nil {
+		utils.Warn("only the domains and buckets registered in context.yaml are treated as environment specific: %s\n", err)
+		return map[string]map[string]bool{}
+	}
+	return bundle.EnvironmentSpecific(recipes)
+}
+
+// printPromotions shows the promotions as a diff of each repo's configuration in the target, returning
+// how many values would be promoted
+func printPromotions(env string, target *manifest.Context, promotions []*manifest.Promotion) (int, error) {
+	byRepo := map[string][]*manifest.Promotion{}
+	repos := make([]string, 0)
+	promoted := 0
+	for _, promotion := range promotions {
+		if promotion.EnvironmentSpecific {
+			utils.Warn("skipping %s.%s, it's specific to each environment\n", promotion.Repo, promotion.Key)
+			continue
+		}
+		if _, ok := byRepo[promotion.Repo]; !ok {
+			repos = append(repos, promotion.Repo)
+		}
+		byRepo[promotion.Repo] = append(byRepo[promotion.Repo], promotion)
+		promoted++
+	}
+
+	for _, repo := range repos {
+		before := target.Configuration[repo]
+		after := map[string]interface{}{}
+		for k, v := range before {
+			after[k] = v
+		}
+		for _, promotion := range byRepo[repo] {
+			after[promotion.Key] = promotion.To
+		}
+
+		diff, err := configDiff(fmt.Sprintf("%s/context.yaml: %s", env, repo), before, after)
+		if err != nil {
+			return 0, err
+		}
+		utils.Highlight("%s\n", repo)
+		fmt.Println(diff)
+	}
+	return promoted, nil
+}
+
+// printVersionPromotions shows the chart versions that differ between the environments, returning how
+// many can be pinned in the target, which are those it has already built
+func printVersionPromotions(from, to string, versions []*manifest.VersionPromotion) int {
+	if len(versions) == 0 {
+		return 0
+	}
+
+	utils.Highlight("chart versions pinned by the last build of each environment:\n")
+	pinned, unbuilt := 0, 0
+	for _, version := range versions {
+		current := version.From
+		if current == "" {
+			current = "not built"
+			unbuilt++
+		} else {
+			pinned++
+		}
+		fmt.Printf("  %s/%s: %s %s -> %s %s\n", version.Repo, version.Chart, to, current, from, version.To)
+	}
+	if unbuilt > 0 {
+		utils.Note("charts %s hasn't built yet are pinned when it is, run `plural --env %s build` to build them\n", to, to)
+	}
+	return pinned
+}
+
+func configDiff(name string, before, after map[string]interface{}) (string, error) {
+	a, err := yaml.Marshal(before)
+	if err != nil {
+		return "", err
+	}
+	b, err := yaml.Marshal(after)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/urfave/cli"
+)
+
+// importRelease adopts a helm release and terraform managed resources that already exist into a built
+// repo, so its first deploy converges on them rather than recreating them
+func (p *Plural) importRelease(c *cli.Context) error {
+	repo := c.Args().Get(0)
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	mapping, err := scaffold.ReadReleaseMapping(c.String("mapping"))
+	if err != nil {
+		return err
+	}
+
+	minimal, err := wkspace.Minimal(repo)
+	if err != nil {
+		return err
+	}
+
+	release := firstSet(c.String("release"), mapping.Release, repo)
+	namespace := firstSet(c.String("namespace"), mapping.Namespace, minimal.Config.Namespace(repo))
+	charts, err := scaffold.Subcharts(root, repo)
+	if err != nil {
+		return err
+	}
+	chart, err := scaffold.SelectSubchart(charts, firstSet(c.String("chart"), mapping.Chart), release)
+	if err != nil {
+		return err
+	}
+
+	if err := minimal.Provider.KubeConfig(); err != nil {
+		return err
+	}
+	values, err := wkspace.ReleaseValues(release, namespace)
+	if err != nil {
+		return err
+	}
+
+	before, after, err := scaffold.ImportReleaseValues(root, repo, chart, values)
+	if err != nil {
+		return err
+	}
+	utils.Highlight("values of release %s in %s, imported under %s\n", release, namespace, chart)
+	for _, change := range scaffold.ChangedValues(before, after) {
+		fmt.Printf("  %-8s %s\n", change.Action, change.Path)
+	}
+	utils.Note("values aren't shown since they can hold secrets, review them in %s once imported\n", filepath.Join(repo, "helm", repo, "values.yaml"))
+
+	ctx, conf, err := importedContext(repo, values, mapping.Context)
+	if err != nil {
+		return err
+	}
+	if len(mapping.Context) > 0 {
+		diff, err := configDiff(fmt.Sprintf("context.yaml: %s", repo), ctx.Configuration[repo], conf)
+		if err != nil {
+			return err
+		}
+		utils.Highlight("configuration read from the release\n")
+		fmt.Println(diff)
+	}
+
+	if len(mapping.Terraform) > 0 {
+		utils.Highlight("terraform resources to import\n")
+		for _, imp := range mapping.Terraform {
+			fmt.Printf("  %s <- %s\n", imp.Address, imp.Id)
+		}
+	}
+
+	if ns := minimal.Config.Namespace(repo); namespace != ns {
+		utils.Warn("plural deploys %s into namespace %s, not %s, so the release's resources will be recreated there\n", repo, ns, namespace)
+	}
+	if release != repo {
+		utils.Note("plural deploys %s as helm release %s, helm only adopts the existing resources annotated with meta.helm.sh/release-name=%s and meta.helm.sh/release-namespace=%s\n",
+			repo, repo, repo, minimal.Config.Namespace(repo))
+	}
+
+	if c.Bool("dry-run") || !confirm(fmt.Sprintf("Import release %s into %s?", release, repo)) {
+		return nil
+	}
+
+	if err := scaffold.WriteReleaseValues(root, repo, before, after); err != nil {
+		return err
+	}
+	if len(mapping.Context) > 0 {
+		ctx.Configuration[repo] = conf
+		if err := ctx.Write(manifest.ContextPath()); err != nil {
+			return err
+		}
+	}
+
+	imported, err := minimal.ImportTerraform(root, mapping.Terraform)
+	if err != nil {
+		return err
+	}
+
+	utils.Success("imported release %s and %d terraform %s into %s\n", release, len(imported), utils.Pluralize("resource", "resources", len(imported)), repo)
+	utils.Note("run `plural deploy` to roll %s out over the imported release\n", repo)
+	return nil
+}
+
+// importedContext reads the workspace's context and the repo's configuration with the values a mapping
+// file reads from the release added to it
+func importedContext(repo string, values map[string]interface{}, paths map[string]string) (*manifest.Context, map[string]interface{}, error) {
+	ctx := manifest.NewContext()
+	if path := manifest.ContextPath(); utils.Exists(path) {
+		var err error
+		if ctx, err = manifest.ReadContext(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	if ctx.Configuration == nil {
+		ctx.Configuration = map[string]map[string]interface{}{}
+	}
+
+	mapped, err := scaffold.ContextValues(values, paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conf := map[string]interface{}{}
+	for k, v := range ctx.Configuration[repo] {
+		conf[k] = v
+	}
+	for k, v := range mapped {
+		conf[k] = v
+	}
+	return ctx, conf, nil
+}
+
+func firstSet(vals ...string) string {
+	for _, val := range vals {
+		if val != "" {
+			return val
+		}
+	}
+	return ""
+}
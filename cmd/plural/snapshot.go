@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/snapshot"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/urfave/cli"
+)
+
+func (p *Plural) snapshot(c *cli.Context) error {
+	if c.GlobalBool("snapshot") {
+		return fmt.Errorf("snapshots have to be taken while the plural api is reachable, drop --snapshot")
+	}
+
+	dir, err := snapshotDir(c.String("dir"))
+	if err != nil {
+		return err
+	}
+
+	project, err := manifest.FetchProject()
+	if err != nil {
+		return err
+	}
+
+	p.InitPluralClient()
+	store, err := snapshot.Create(dir)
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Capture(p.Client, store, project.Provider); err != nil {
+		return err
+	}
+
+	utils.Success("Snapshot written to %s, build from it with `plural --snapshot build`\n", dir)
+	return nil
+}
+
+// setupSnapshot points every api client and package download at the workspace's snapshot when
+// running with --snapshot
+func setupSnapshot(c *cli.Context) error {
+	if !c.GlobalBool("snapshot") {
+		return nil
+	}
+
+	dir, err := snapshotDir(os.Getenv("PLURAL_SNAPSHOT_DIR"))
+	if err != nil {
+		return err
+	}
+
+	store, err := snapshot.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	api.UseOffline(snapshot.NewClient(store))
+	http.DefaultClient.Transport = snapshot.NewTransport(store)
+	// plural commands run by build steps should use the snapshot too
+	return os.Setenv("PLURAL_SNAPSHOT", "true")
+}
+
+func snapshotDir(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
+	root, err := git.Root()
+	if err != nil {
+		return "", err
+	}
+	return snapshot.DefaultDir(root), nil
+}
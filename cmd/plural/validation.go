@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/config"
 	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/lock"
 	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
@@ -49,6 +51,32 @@ func owned(fn func(*cli.Context) error) func(*cli.Context) error {
 	}
 }
 
+// locked holds the workspace lock for the duration of the command, so concurrent deploys fail fast.
+// Dry runs and build checks don't touch the workspace, so they run without it
+func locked(fn func(*cli.Context) error) func(*cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.Bool("dry-run") || c.Bool("check") {
+			return fn(c)
+		}
+
+		root, err := git.Root()
+		if err != nil {
+			return err
+		}
+
+		release, err := lock.Acquire(root, c.Command.Name)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c)
+		if rerr := release(); rerr != nil && err == nil {
+			err = rerr
+		}
+		return err
+	}
+}
+
 func affirmed(fn func(*cli.Context) error, msg string) func(*cli.Context) error {
 	return func(c *cli.Context) error {
 		if ok := affirm(msg); !ok {
@@ -65,7 +93,8 @@ func tracked(fn func(*cli.Context) error, event string) func(*cli.Context) error
 		err := fn(c)
 		if err != nil {
 			event.Status = "ERROR"
-			if we, ok := err.(*executor.WrappedError); ok { //nolint:errorlint
+			var we *executor.WrappedError
+			if errors.As(err, &we) {
 				event.Data = we.Output
 			} else {
 				event.Data = fmt.Sprint(err)
@@ -94,10 +94,12 @@ func (client *client) GetPackageInstallations(repoId string) (charts []*ChartIns
 		}
 	}
 
-	if err == nil {
-		packageCache[repoId] = &packageCacheEntry{Charts: charts, Terraform: tfs}
+	if err = client.markOptional(repoId, charts, tfs); err != nil {
+		return
 	}
 
+	packageCache[repoId] = &packageCacheEntry{Charts: charts, Terraform: tfs}
+
 	return
 }
 
@@ -82,7 +82,19 @@ func NewClient() Client {
 	return FromConfig(&conf)
 }
 
+// offline, when set, replaces every client so nothing talks to the api
+var offline Client
+
+// UseOffline makes NewClient and FromConfig return client instead of a client of the api
+func UseOffline(client Client) {
+	offline = client
+}
+
 func FromConfig(conf *config.Config) Client {
+	if offline != nil {
+		return offline
+	}
+
 	httpClient := http.Client{
 		Transport: &authedTransport{
 			key:     conf.Token,
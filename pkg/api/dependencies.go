@@ -0,0 +1,102 @@
+package api
+
+import "github.com/pluralsh/gqlclient/pkg/utils"
+
+// optionalDependenciesDocument selects whether each package dependency is optional, which the
+// DependenciesFragment of gqlclient leaves out
+const optionalDependenciesDocument = `query OptionalDependencies ($id: ID!) {
+	chartInstallations(repositoryId: $id, first: 100) {
+		edges { node { chart { name dependencies { ...OptionalDependencies } } } }
+	}
+	terraformInstallations(repositoryId: $id, first: 100) {
+		edges { node { terraform { name dependencies { ...OptionalDependencies } } } }
+	}
+}
+fragment OptionalDependencies on Dependencies {
+	dependencies { type name repo optional }
+}
+`
+
+type optionalDependencies struct {
+	Name         string `json:"name"`
+	Dependencies *struct {
+		Dependencies []*struct {
+			Type     *string `json:"type"`
+			Name     *string `json:"name"`
+			Repo     *string `json:"repo"`
+			Optional *bool   `json:"optional"`
+		} `json:"dependencies"`
+	} `json:"dependencies"`
+}
+
+type optionalDependenciesResponse struct {
+	ChartInstallations struct {
+		Edges []*struct {
+			Node *struct {
+				Chart *optionalDependencies `json:"chart"`
+			} `json:"node"`
+		} `json:"edges"`
+	} `json:"chartInstallations"`
+	TerraformInstallations struct {
+		Edges []*struct {
+			Node *struct {
+				Terraform *optionalDependencies `json:"terraform"`
+			} `json:"node"`
+		} `json:"edges"`
+	} `json:"terraformInstallations"`
+}
+
+// markOptional flags the optional dependencies of a repo's chart and terraform packages
+func (client *client) markOptional(repoId string, charts []*ChartInstallation, tfs []*TerraformInstallation) error {
+	var resp optionalDependenciesResponse
+	vars := map[string]interface{}{"id": repoId}
+	if err := client.pluralClient.Client.Post(client.ctx, "OptionalDependencies", optionalDependenciesDocument, &resp, vars); err != nil {
+		return err
+	}
+
+	optional := make(map[string]bool)
+	for _, edge := range resp.ChartInstallations.Edges {
+		if edge.Node != nil {
+			collectOptional(optional, "chart", edge.Node.Chart)
+		}
+	}
+	for _, edge := range resp.TerraformInstallations.Edges {
+		if edge.Node != nil {
+			collectOptional(optional, "terraform", edge.Node.Terraform)
+		}
+	}
+
+	for _, chart := range charts {
+		if chart.Chart != nil && chart.Chart.Dependencies != nil {
+			applyOptional(optional, "chart", chart.Chart.Name, chart.Chart.Dependencies.Dependencies)
+		}
+	}
+	for _, tf := range tfs {
+		if tf.Terraform != nil && tf.Terraform.Dependencies != nil {
+			applyOptional(optional, "terraform", tf.Terraform.Name, tf.Terraform.Dependencies.Dependencies)
+		}
+	}
+	return nil
+}
+
+func optionalKey(kind, pkg, typ, repo, name string) string {
+	return kind + "/" + pkg + "/" + typ + "/" + repo + "/" + name
+}
+
+func collectOptional(optional map[string]bool, kind string, pkg *optionalDependencies) {
+	if pkg == nil || pkg.Dependencies == nil {
+		return
+	}
+
+	for _, dep := range pkg.Dependencies.Dependencies {
+		if dep.Optional != nil && *dep.Optional && dep.Type != nil {
+			optional[optionalKey(kind, pkg.Name, *dep.Type, utils.ConvertStringPointer(dep.Repo), utils.ConvertStringPointer(dep.Name))] = true
+		}
+	}
+}
+
+func applyOptional(optional map[string]bool, kind, pkg string, deps []*Dependency) {
+	for _, dep := range deps {
+		dep.Optional = optional[optionalKey(kind, pkg, dep.Type, dep.Repo, dep.Name)]
+	}
+}
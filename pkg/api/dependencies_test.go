@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const packageInstallations = `{"data": {
+	"chartInstallations": {"edges": [{"node": {"id": "c1", "chart": {"id": "c", "name": "airflow", "dependencies": {
+		"dependencies": [{"type": "HELM", "name": "postgres", "repo": "postgres"}, {"type": "HELM", "name": "monitoring", "repo": "monitoring"}]
+	}}}}]},
+	"terraformInstallations": {"edges": [{"node": {"id": "t1", "terraform": {"id": "t", "name": "aws", "dependencies": {
+		"dependencies": [{"type": "TERRAFORM", "name": "aws", "repo": "bootstrap"}]
+	}}}}]}
+}}`
+
+const optionalDependencies = `{"data": {
+	"chartInstallations": {"edges": [{"node": {"chart": {"name": "airflow", "dependencies": {
+		"dependencies": [{"type": "HELM", "name": "postgres", "repo": "postgres", "optional": false}, {"type": "HELM", "name": "monitoring", "repo": "monitoring", "optional": true}]
+	}}}}]},
+	"terraformInstallations": {"edges": [{"node": {"terraform": {"name": "aws", "dependencies": {
+		"dependencies": [{"type": "TERRAFORM", "name": "aws", "repo": "bootstrap", "optional": null}]
+	}}}}]}
+}}`
+
+func TestGetPackageInstallationsOptional(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string `json:"operationName"`
+			Query         string `json:"query"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		w.Header().Set("Content-Type", "application/json")
+		if req.OperationName == "OptionalDependencies" {
+			assert.Contains(t, req.Query, "optional")
+			_, _ = w.Write([]byte(optionalDependencies))
+			return
+		}
+		_, _ = w.Write([]byte(packageInstallations))
+	}))
+	defer server.Close()
+
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	defer func() { http.DefaultTransport = transport }()
+
+	client := api.FromConfig(&config.Config{Endpoint: strings.TrimPrefix(server.URL, "https://")})
+	charts, tfs, err := client.GetPackageInstallations("optional-dependencies")
+	assert.NoError(t, err)
+
+	deps := charts[0].Chart.Dependencies.Dependencies
+	assert.Equal(t, "postgres", deps[0].Repo)
+	assert.False(t, deps[0].Optional)
+	assert.Equal(t, "monitoring", deps[1].Repo)
+	assert.True(t, deps[1].Optional)
+	assert.False(t, tfs[0].Terraform.Dependencies.Dependencies[0].Optional)
+}
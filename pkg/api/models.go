@@ -79,6 +79,8 @@ type Dependency struct {
 	Type string
 	Repo string
 	Name string
+	// Optional dependencies are ones the package works without
+	Optional bool
 }
 
 type Wirings struct {
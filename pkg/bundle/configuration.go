@@ -25,11 +25,11 @@ func evaluateCondition(ctx map[string]interface{}, cond *api.Condition) bool {
 		booled, ok := val.(bool)
 		return ok && !booled
 	case "PREFIX":
-		val := ctx[cond.Field]
-		return strings.HasPrefix(val.(string), cond.Value)
+		val, _ := ctx[cond.Field].(string)
+		return strings.HasPrefix(val, cond.Value)
 	case "SUFFIX":
-		val := ctx[cond.Field]
-		return strings.HasSuffix(val.(string), cond.Value)
+		val, _ := ctx[cond.Field].(string)
+		return strings.HasSuffix(val, cond.Value)
 	}
 
 	return true
@@ -0,0 +1,155 @@
+package bundle
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+const bucketRegex = "[a-z][a-z0-9\\-]+[a-z0-9]"
+
+// ValidationError is a problem with a repo's configuration, or with its key when one is set
+type ValidationError struct {
+	Repo    string `json:"repo"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s", e.Repo, e.Message)
+	}
+	return fmt.Sprintf("%s.%s: %s", e.Repo, e.Key, e.Message)
+}
+
+// ValidateContext checks the configuration in context.yaml against the configuration items of the
+// recipes it was installed with, the same way configuring them interactively would.  Domains and buckets
+// are only checked for uniqueness against each other within context.yaml, not against ones claimed
+// elsewhere.  Every problem found is returned, rather than just the first
+func ValidateContext(ctx *manifest.Context, recipes []*api.Recipe) []*ValidationError {
+	errs := make([]*ValidationError, 0)
+	seen := make(map[string]bool)
+	owners := map[string]map[string]string{Domain: {}, Bucket: {}}
+
+	for _, recipe := range recipes {
+		for _, section := range recipe.RecipeSections {
+			repo := section.Repository.Name
+			conf, _ := ctx.Repo(repo)
+			if conf == nil {
+				conf = map[string]interface{}{}
+			}
+
+			for _, item := range section.Configuration {
+				key := fmt.Sprintf("%s.%s", repo, item.Name)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				if !evaluateCondition(conf, item.Condition) {
+					continue
+				}
+
+				if err := validateItem(conf, item); err != nil {
+					errs = append(errs, &ValidationError{Repo: repo, Key: item.Name, Message: err.Error()})
+					continue
+				}
+
+				if names, ok := owners[item.Type]; ok {
+					val, _ := conf[item.Name].(string)
+					if val == "" {
+						continue
+					}
+					if owner, ok := names[val]; ok {
+						errs = append(errs, &ValidationError{Repo: repo, Key: item.Name, Message: fmt.Sprintf("%s %s is already used by %s", item.Type, val, owner)})
+						continue
+					}
+					names[val] = key
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Repo != errs[j].Repo {
+			return errs[i].Repo < errs[j].Repo
+		}
+		return errs[i].Key < errs[j].Key
+	})
+	return errs
+}
+
+// EnvironmentSpecific lists the domain and bucket configuration items of recipes by repo, their values
+// have to differ between environments
+func EnvironmentSpecific(recipes []*api.Recipe) map[string]map[string]bool {
+	specific := make(map[string]map[string]bool)
+	for _, recipe := range recipes {
+		for _, section := range recipe.RecipeSections {
+			for _, item := range section.Configuration {
+				if item.Type != Domain && item.Type != Bucket {
+					continue
+				}
+
+				repo := section.Repository.Name
+				if _, ok := specific[repo]; !ok {
+					specific[repo] = make(map[string]bool)
+				}
+				specific[repo][item.Name] = true
+			}
+		}
+	}
+	return specific
+}
+
+func validateItem(conf map[string]interface{}, item *api.ConfigurationItem) error {
+	val, ok := conf[item.Name]
+	if !ok || val == nil || val == "" {
+		if item.Optional {
+			return nil
+		}
+		return fmt.Errorf("is required")
+	}
+
+	switch item.Type {
+	case Int:
+		switch val.(type) {
+		case int, int64, uint64:
+		default:
+			return fmt.Errorf("must be an integer, got %v", val)
+		}
+	case Bool:
+		if _, ok := val.(bool); !ok {
+			return fmt.Errorf("must be true or false, got %v", val)
+		}
+	case Domain:
+		str, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("must be a domain, got %v", val)
+		}
+		if err := utils.ValidateDns(str); err != nil {
+			return fmt.Errorf("%s is not a dns compliant hostname", str)
+		}
+	case Bucket:
+		str, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("must be a bucket name, got %v", val)
+		}
+		if err := utils.ValidateRegex(str, bucketRegex, ""); err != nil {
+			return fmt.Errorf("%s is not a hyphenated alphanumeric bucket name", str)
+		}
+	case String, Password, File:
+		if _, ok := val.(string); !ok {
+			return fmt.Errorf("must be a string, got %v", val)
+		}
+	}
+
+	if str, ok := val.(string); ok && item.Validation != nil && item.Validation.Type == "REGEX" {
+		if err := utils.ValidateRegex(str, item.Validation.Regex, item.Validation.Message); err != nil {
+			return fmt.Errorf("%s", item.Validation.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package bundle_test
+
+import (
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/bundle"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func section(repo string, items ...*api.ConfigurationItem) *api.RecipeSection {
+	return &api.RecipeSection{Repository: &api.Repository{Name: repo}, Configuration: items}
+}
+
+func TestValidateContext(t *testing.T) {
+	recipes := []*api.Recipe{{
+		RecipeSections: []*api.RecipeSection{
+			section("airflow",
+				&api.ConfigurationItem{Name: "hostname", Type: bundle.Domain},
+				&api.ConfigurationItem{Name: "airflowBucket", Type: bundle.Bucket},
+				&api.ConfigurationItem{Name: "replicas", Type: bundle.Int},
+				&api.ConfigurationItem{Name: "private", Type: bundle.Bool, Optional: true},
+				&api.ConfigurationItem{Name: "sshKey", Type: bundle.File, Condition: &api.Condition{Field: "gitUrl", Operation: "PREFIX", Value: "git@"}},
+				&api.ConfigurationItem{Name: "gitUrl", Type: bundle.String, Optional: true, Validation: &api.Validation{Type: "REGEX", Regex: "(git@|https://).*", Message: "must be a git url"}},
+			),
+			section("postgres",
+				&api.ConfigurationItem{Name: "wal_bucket", Type: bundle.Bucket},
+			),
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		config   map[string]map[string]interface{}
+		expected []string
+	}{
+		{
+			name: `test a valid context`,
+			config: map[string]map[string]interface{}{
+				"airflow":  {"hostname": "airflow.example.com", "airflowBucket": "airflow-logs", "replicas": 2},
+				"postgres": {"wal_bucket": "postgres-wal"},
+			},
+			expected: []string{},
+		},
+		{
+			name: `test every problem is reported`,
+			config: map[string]map[string]interface{}{
+				"airflow":  {"hostname": "not a domain", "airflowBucket": "shared-bucket", "replicas": "two", "private": "yes", "gitUrl": "git@github.com:org/repo"},
+				"postgres": {"wal_bucket": "shared-bucket"},
+			},
+			expected: []string{
+				"airflow.hostname: not a domain is not a dns compliant hostname",
+				"airflow.private: must be true or false, got yes",
+				"airflow.replicas: must be an integer, got two",
+				"airflow.sshKey: is required",
+				"postgres.wal_bucket: BUCKET shared-bucket is already used by airflow.airflowBucket",
+			},
+		},
+		{
+			name: `test validation regexes and missing repos`,
+			config: map[string]map[string]interface{}{
+				"airflow": {"hostname": "airflow.example.com", "airflowBucket": "airflow-logs", "replicas": 2, "gitUrl": "ftp://repo"},
+			},
+			expected: []string{
+				"airflow.gitUrl: must be a git url",
+				"postgres.wal_bucket: is required",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := manifest.NewContext()
+			ctx.Configuration = test.config
+
+			errs := []string{}
+			for _, err := range bundle.ValidateContext(ctx, recipes) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, test.expected, errs)
+		})
+	}
+}
+
+func TestEnvironmentSpecific(t *testing.T) {
+	recipes := []*api.Recipe{{
+		RecipeSections: []*api.RecipeSection{
+			section("airflow",
+				&api.ConfigurationItem{Name: "hostname", Type: bundle.Domain},
+				&api.ConfigurationItem{Name: "airflowBucket", Type: bundle.Bucket},
+				&api.ConfigurationItem{Name: "replicas", Type: bundle.Int},
+			),
+			section("postgres",
+				&api.ConfigurationItem{Name: "wal_bucket", Type: bundle.Bucket},
+			),
+		},
+	}}
+
+	assert.Equal(t, map[string]map[string]bool{
+		"airflow":  {"hostname": true, "airflowBucket": true},
+		"postgres": {"wal_bucket": true},
+	}, bundle.EnvironmentSpecific(recipes))
+}
@@ -35,7 +35,16 @@ type Config struct {
 	Endpoint        string `yaml:"endpoint"`
 	LockProfile     string `yaml:"lockProfile"`
 	ReportErrors    bool   `yaml:"reportErrors"`
-	metadata        *Metadata
+	// Notifications are webhooks told about every deploy, destroy and bounce run from this profile
+	Notifications []*Webhook `yaml:"notifications,omitempty"`
+	metadata      *Metadata
+}
+
+// Webhook is a notification endpoint, configured like the notifications of workspace.yaml
+type Webhook struct {
+	Url    string   `yaml:"url"`
+	Format string   `yaml:"format,omitempty"`
+	Events []string `yaml:"events,omitempty"`
 }
 
 type VersionedConfig struct {
@@ -321,6 +321,6 @@ func getAgePath() string {
 }
 
 func cryptPath() string {
-	root, _ := git.Root()
+	root, _ := git.Toplevel()
 	return pathing.SanitizeFilepath(filepath.Join(root, ".plural-crypt"))
 }
@@ -1,6 +1,7 @@
 package crypto
 
 import (
+	"errors"
 	"io/ioutil"
 	"path/filepath"
 
@@ -32,6 +33,35 @@ func ReadConfig() (conf *Config, err error) {
 	return
 }
 
+// ErrNoKey is returned when this machine has no key to decrypt the repo with
+var ErrNoKey = errors.New("no encryption key found")
+
+// LocalId fingerprints the key this machine decrypts the repo with, to compare against the id in
+// crypto.yml.  Unlike Build it never generates a missing key, returning ErrNoKey instead
+func LocalId(conf *Config) (string, error) {
+	switch conf.Type {
+	case AGE:
+		if !utils.Exists(getAgePath()) {
+			return "", ErrNoKey
+		}
+		prov, err := BuildAgeProvider()
+		if err != nil {
+			return "", err
+		}
+		return prov.ID(), nil
+	default:
+		if !utils.Exists(getKeyPath()) {
+			return "", ErrNoKey
+		}
+		key, err := Read(getKeyPath())
+		if err != nil {
+			return "", err
+		}
+		prov := &KeyProvider{key: key.Key}
+		return prov.ID(), nil
+	}
+}
+
 func Build() (Provider, error) {
 	if utils.Exists(configPath()) {
 		conf, err := ReadConfig()
@@ -17,6 +17,14 @@ const (
 	AGE IdentityType = "age"
 )
 
+// GitFilters is the git config that transparently encrypts and decrypts a repo's secrets
+var GitFilters = [][]string{
+	{"filter.plural-crypt.smudge", "plural crypto decrypt"},
+	{"filter.plural-crypt.clean", "plural crypto encrypt"},
+	{"filter.plural-crypt.required", "true"},
+	{"diff.plural-crypt.textconv", "plural crypto decrypt"},
+}
+
 func Encrypt(prov Provider, text []byte) ([]byte, error) {
 	key, err := prov.SymmetricKey()
 	if err != nil {
@@ -1,7 +1,9 @@
 package diff
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -18,6 +20,9 @@ import (
 type Diff struct {
 	Metadata Metadata         `hcl:"metadata"`
 	Steps    []*executor.Step `hcl:"step"`
+
+	// Out is where diff output is written, defaulting to stdout
+	Out io.Writer `hcle:"omit"`
 }
 
 type Metadata struct {
@@ -43,7 +48,7 @@ func (e *Diff) Execute() error {
 		return err
 	}
 
-	path := pathing.SanitizeFilepath(filepath.Join(root, "diffs"))
+	path := pathing.SanitizeFilepath(filepath.Join(root, "diffs", e.Metadata.Path))
 	if err := os.MkdirAll(path, os.ModePerm); err != nil {
 		return err
 	}
@@ -57,9 +62,17 @@ func (e *Diff) Execute() error {
 		return err
 	}
 
-	fmt.Printf("deploying %s, hold on to your butts\n", e.Metadata.Path)
+	out := e.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	fmt.Fprintf(out, "deploying %s, hold on to your butts\n", e.Metadata.Path)
 	for i, step := range e.Steps {
-		newSha, err := step.Execute(root, ignore)
+		step.Repo = e.Metadata.Path
+		step.Out = out
+		step.LogDir = executor.LogDir(root, e.Metadata.Path)
+		newSha, err := step.Execute(context.Background(), root, ignore)
 		if err != nil {
 			if err := e.Flush(root); err != nil {
 				return err
@@ -93,7 +106,9 @@ func (e *Diff) IgnoreFile(root string) ([]string, error) {
 	return result, nil
 }
 
-func DefaultDiff(path string, prev *Diff) (e *Diff) {
+// DefaultDiff merges the default diff steps into the previous diff.hcl, failing if their orders
+// contradict each other
+func DefaultDiff(path string, prev *Diff) (*Diff, error) {
 	byName := make(map[string]*executor.Step)
 	steps := []*executor.Step{
 		{
@@ -135,9 +150,8 @@ func DefaultDiff(path string, prev *Diff) (e *Diff) {
 	}
 
 	for _, step := range steps {
-		prev, ok := byName[step.Name]
-		if ok {
-			step.Sha = prev.Sha
+		if prev, ok := byName[step.Name]; ok {
+			step.Inherit(prev)
 		}
 		byName[step.Name] = step
 	}
@@ -159,7 +173,7 @@ func DefaultDiff(path string, prev *Diff) (e *Diff) {
 	finalizedSteps := []*executor.Step{}
 	sorted, ok := graph.Topsort()
 	if !ok {
-		panic("deployfile cycle detected")
+		return nil, fmt.Errorf("the order of steps %s in %s/diff.hcl conflicts with the default order, reorder or remove them", utils.FormatCycle(graph.Cycle()), path)
 	}
 
 	// dump the topsort to a list and use that from now on
@@ -170,7 +184,7 @@ func DefaultDiff(path string, prev *Diff) (e *Diff) {
 	return &Diff{
 		Metadata: Metadata{Path: path, Name: "diff"},
 		Steps:    finalizedSteps,
-	}
+	}, nil
 }
 
 func (d *Diff) Flush(root string) error {
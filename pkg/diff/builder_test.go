@@ -0,0 +1,41 @@
+package diff_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultDiffKeepsEdits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "app"), 0755))
+
+	d, err := diff.DefaultDiff("app", &diff.Diff{})
+	assert.NoError(t, err)
+	assert.NoError(t, d.Flush(dir))
+
+	edited, err := diff.GetDiff(filepath.Join(dir, "app"), "diff")
+	assert.NoError(t, err)
+	policy := &executor.RetryPolicy{MaxAttempts: 2, Backoff: "5s"}
+	edited.Steps[0].Retry = policy
+	edited.Steps[0].Timeout = "10m"
+	assert.NoError(t, edited.Flush(dir))
+
+	prev, err := diff.GetDiff(filepath.Join(dir, "app"), "diff")
+	assert.NoError(t, err)
+	rebuilt, err := diff.DefaultDiff("app", prev)
+	assert.NoError(t, err)
+	assert.NoError(t, rebuilt.Flush(dir))
+
+	read, err := diff.GetDiff(filepath.Join(dir, "app"), "diff")
+	assert.NoError(t, err)
+	assert.Equal(t, policy, read.Steps[0].Retry)
+	assert.Equal(t, "10m", read.Steps[0].Timeout)
+}
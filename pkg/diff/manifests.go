@@ -0,0 +1,231 @@
+package diff
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+// SnapshotPath is where the rendered manifests of the last successful helm deploy of a repo are kept
+func SnapshotPath(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, ".plural", "manifests.yaml"))
+}
+
+type manifestObject struct {
+	key  string
+	kind string
+	ns   string
+	name string
+	body map[interface{}]interface{}
+}
+
+// MaskManifests masks secret values in rendered kubernetes manifests: all data in Secrets, values of
+// secret-looking keys, and env vars with secret-looking names.  Objects come out sorted, so snapshots
+// of unchanged charts are byte for byte identical
+func MaskManifests(rendered []byte) ([]byte, error) {
+	objects, err := parseManifests(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, obj := range objects {
+		contents, err := yaml.Marshal(obj.body)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(contents)
+	}
+	return buf.Bytes(), nil
+}
+
+// DiffManifests compares two sets of rendered manifests object by object, reporting the field paths
+// that differ in changed objects
+func DiffManifests(before, after []byte) ([]*ObjectChange, error) {
+	old, err := parseManifests(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := parseManifests(after)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := map[string]*manifestObject{}
+	for _, obj := range old {
+		byKey[obj.key] = obj
+	}
+
+	changes := make([]*ObjectChange, 0)
+	for _, obj := range current {
+		prev, ok := byKey[obj.key]
+		delete(byKey, obj.key)
+		if !ok {
+			fields := flatten("", obj.body)
+			changes = append(changes, obj.change(ActionAdded, len(fields), 0, nil))
+			continue
+		}
+
+		oldFields, newFields := flatten("", prev.body), flatten("", obj.body)
+		added, removed, paths := 0, 0, []string{}
+		for path, val := range newFields {
+			oldVal, ok := oldFields[path]
+			switch {
+			case !ok:
+				added++
+			case !reflect.DeepEqual(oldVal, val):
+				added++
+				removed++
+			default:
+				continue
+			}
+			paths = append(paths, path)
+		}
+		for path := range oldFields {
+			if _, ok := newFields[path]; !ok {
+				removed++
+				paths = append(paths, path)
+			}
+		}
+
+		if len(paths) > 0 {
+			sort.Strings(paths)
+			changes = append(changes, obj.change(ActionChanged, added, removed, paths))
+		}
+	}
+
+	for _, obj := range old {
+		if _, ok := byKey[obj.key]; ok {
+			changes = append(changes, obj.change(ActionRemoved, 0, len(flatten("", obj.body)), nil))
+		}
+	}
+	return changes, nil
+}
+
+func (obj *manifestObject) change(action Action, added, removed int, fields []string) *ObjectChange {
+	return &ObjectChange{
+		Namespace: obj.ns,
+		Name:      obj.name,
+		Kind:      obj.kind,
+		Action:    action,
+		Added:     added,
+		Removed:   removed,
+		Fields:    fields,
+	}
+}
+
+func parseManifests(rendered []byte) ([]*manifestObject, error) {
+	objects := make([]*manifestObject, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(rendered))
+	for {
+		body := map[interface{}]interface{}{}
+		err := dec.Decode(&body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			continue
+		}
+
+		obj := &manifestObject{body: body, kind: str(body["kind"])}
+		if meta, ok := body["metadata"].(map[interface{}]interface{}); ok {
+			obj.name, obj.ns = str(meta["name"]), str(meta["namespace"])
+		}
+		obj.key = fmt.Sprintf("%s/%s/%s/%s", str(body["apiVersion"]), obj.kind, obj.ns, obj.name)
+		mask(obj)
+		objects = append(objects, obj)
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].key < objects[j].key })
+	return objects, nil
+}
+
+func mask(obj *manifestObject) {
+	if obj.kind == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			if data, ok := obj.body[field].(map[interface{}]interface{}); ok {
+				for key := range data {
+					data[key] = masked
+				}
+			}
+		}
+	}
+	maskValue(obj.body)
+}
+
+func maskValue(val interface{}) {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		// env vars and similar name/value pairs
+		if name, ok := v["name"].(string); ok && secretField(name) {
+			if _, ok := v["value"]; ok {
+				v["value"] = masked
+			}
+		}
+
+		for key, child := range v {
+			if _, scalar := child.(string); scalar && secretField(str(key)) {
+				v[key] = masked
+				continue
+			}
+			maskValue(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			maskValue(child)
+		}
+	}
+}
+
+// secretField matches keys holding secret values, but not references to secrets like secretName
+func secretField(key string) bool {
+	lower := strings.ToLower(key)
+	if strings.HasSuffix(lower, "name") || strings.HasSuffix(lower, "ref") {
+		return false
+	}
+	return secretKey.MatchString(key)
+}
+
+func flatten(prefix string, val interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			path := str(key)
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			for k, leaf := range flatten(path, child) {
+				result[k] = leaf
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			for k, leaf := range flatten(fmt.Sprintf("%s[%d]", prefix, i), child) {
+				result[k] = leaf
+			}
+		}
+	default:
+		result[prefix] = v
+	}
+	return result
+}
+
+func str(val interface{}) string {
+	if val == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(val))
+}
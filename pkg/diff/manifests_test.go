@@ -0,0 +1,120 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+const deployed = `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: airflow-creds
+  namespace: airflow
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: airflow-web
+  namespace: airflow
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: airflow:2.1.0
+        env:
+        - name: DB_PASSWORD
+          value: hunter2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: airflow-old
+  namespace: airflow
+data:
+  key: value
+`
+
+const rendered = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: airflow-web
+  namespace: airflow
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: airflow:2.1.0
+        env:
+        - name: DB_PASSWORD
+          value: hunter3
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: airflow-creds
+  namespace: airflow
+data:
+  password: aHVudGVyMw==
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: airflow-web
+  namespace: airflow
+spec:
+  ports:
+  - port: 80
+`
+
+func TestMaskManifests(t *testing.T) {
+	masked, err := diff.MaskManifests([]byte(deployed))
+	assert.NoError(t, err)
+
+	out := string(masked)
+	assert.NotContains(t, out, "aHVudGVyMg==")
+	assert.NotContains(t, out, "hunter2")
+	assert.Contains(t, out, "name: DB_PASSWORD")
+	assert.Contains(t, out, "image: airflow:2.1.0")
+	assert.Less(t, strings.Index(out, "kind: Deployment"), strings.Index(out, "kind: ConfigMap"))
+}
+
+func TestDiffManifests(t *testing.T) {
+	changes, err := diff.DiffManifests([]byte(deployed), []byte(rendered))
+	assert.NoError(t, err)
+
+	byKind := map[string]*diff.ObjectChange{}
+	for _, change := range changes {
+		byKind[change.Kind] = change
+	}
+	assert.Len(t, changes, 3)
+
+	tests := []struct {
+		kind   string
+		action diff.Action
+		fields []string
+	}{
+		{kind: "Deployment", action: diff.ActionChanged, fields: []string{"spec.replicas"}},
+		{kind: "Service", action: diff.ActionAdded},
+		{kind: "ConfigMap", action: diff.ActionRemoved},
+	}
+	for _, test := range tests {
+		t.Run(test.kind, func(t *testing.T) {
+			change, ok := byKind[test.kind]
+			assert.True(t, ok)
+			assert.Equal(t, test.action, change.Action)
+			assert.Equal(t, "airflow", change.Namespace)
+			assert.Equal(t, test.fields, change.Fields)
+		})
+	}
+}
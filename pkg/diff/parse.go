@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"bufio"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	masked        = "(sensitive)"
+	unknown       = "(known after apply)"
+	maxValueWidth = 80
+)
+
+var (
+	helmHeader = regexp.MustCompile(`^(\S[^,]*), ([^,]+), (.+?) has (changed|been added|been removed):\s*$`)
+	secretKey  = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|access_?key|private_?key|credentials?)`)
+)
+
+type tfPlan struct {
+	ResourceChanges []*tfResourceChange `json:"resource_changes"`
+}
+
+type tfResourceChange struct {
+	Address string `json:"address"`
+	Type    string `json:"type"`
+	Change  struct {
+		Actions         []string        `json:"actions"`
+		Before          json.RawMessage `json:"before"`
+		After           json.RawMessage `json:"after"`
+		AfterUnknown    json.RawMessage `json:"after_unknown"`
+		BeforeSensitive json.RawMessage `json:"before_sensitive"`
+		AfterSensitive  json.RawMessage `json:"after_sensitive"`
+	} `json:"change"`
+}
+
+// ParseTerraformPlan summarizes the output of `terraform show -json` for a saved plan.  Only the top
+// level attributes of updated and replaced resources are kept, with sensitive values masked
+func ParseTerraformPlan(contents []byte) ([]*ResourceChange, error) {
+	plan := &tfPlan{}
+	if err := json.Unmarshal(contents, plan); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*ResourceChange, 0)
+	for _, rc := range plan.ResourceChanges {
+		action := terraformAction(rc.Change.Actions)
+		if action == "" {
+			continue
+		}
+
+		change := &ResourceChange{Address: rc.Address, Type: rc.Type, Action: action}
+		if action == ActionUpdate || action == ActionReplace {
+			change.Attributes = attributeChanges(rc)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func terraformAction(actions []string) Action {
+	switch strings.Join(actions, ",") {
+	case "create":
+		return ActionCreate
+	case "update":
+		return ActionUpdate
+	case "delete":
+		return ActionDestroy
+	case "delete,create", "create,delete":
+		return ActionReplace
+	default:
+		// no-op and read
+		return ""
+	}
+}
+
+func attributeChanges(rc *tfResourceChange) []*AttributeChange {
+	before, after := objectOf(rc.Change.Before), objectOf(rc.Change.After)
+	unknowns := objectOf(rc.Change.AfterUnknown)
+	beforeSensitive, afterSensitive := sensitivity(rc.Change.BeforeSensitive), sensitivity(rc.Change.AfterSensitive)
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	for key := range unknowns {
+		keys[key] = true
+	}
+
+	result := make([]*AttributeChange, 0)
+	for key := range keys {
+		b, a := render(before[key]), render(after[key])
+		if isTrue(unknowns[key]) {
+			a = unknown
+		}
+		if b == a {
+			continue
+		}
+
+		// a secret anywhere within the attribute masks the whole value on both sides, a partial mask
+		// would still show which nested fields changed and what they changed from
+		if beforeSensitive.masks(key) || afterSensitive.masks(key) || secretKey.MatchString(key) || hasSecretKey(before[key]) || hasSecretKey(after[key]) {
+			b = masked
+			if a != unknown {
+				a = masked
+			}
+		}
+		result = append(result, &AttributeChange{Name: key, Before: b, After: a})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// sensitive is terraform's sensitivity marker, either true for the whole object or a map of attributes
+type sensitive struct {
+	all    bool
+	fields map[string]json.RawMessage
+}
+
+func sensitivity(raw json.RawMessage) *sensitive {
+	s := &sensitive{all: isTrue(raw)}
+	s.fields = objectOf(raw)
+	return s
+}
+
+func (s *sensitive) masks(key string) bool {
+	if s.all {
+		return true
+	}
+
+	val, ok := s.fields[key]
+	if !ok {
+		return false
+	}
+
+	// nested markers are maps or lists of markers, any of which may be set
+	return isTrue(val) || strings.Contains(string(val), "true")
+}
+
+// hasSecretKey reports whether any object nested within a value has a secret-looking key
+func hasSecretKey(raw json.RawMessage) bool {
+	var val interface{}
+	if err := json.Unmarshal(raw, &val); err != nil {
+		return false
+	}
+	return containsSecretKey(val)
+}
+
+func containsSecretKey(val interface{}) bool {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if secretKey.MatchString(key) || containsSecretKey(nested) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			if containsSecretKey(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func objectOf(raw json.RawMessage) map[string]json.RawMessage {
+	result := map[string]json.RawMessage{}
+	_ = json.Unmarshal(raw, &result)
+	return result
+}
+
+func isTrue(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "true"
+}
+
+func render(raw json.RawMessage) string {
+	val := strings.TrimSpace(string(raw))
+	if val == "" {
+		val = "null"
+	}
+	if len(val) > maxValueWidth {
+		val = val[:maxValueWidth-3] + "..."
+	}
+	return val
+}
+
+// ParseHelmDiff summarizes `helm diff` output into the kubernetes objects it changes, along with the
+// number of lines added and removed in each.  Object contents are never kept, so secrets can't leak
+func ParseHelmDiff(contents []byte) []*ObjectChange {
+	changes := make([]*ObjectChange, 0)
+	var current *ObjectChange
+
+	scanner := bufio.NewScanner(strings.NewReader(string(contents)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := helmHeader.FindStringSubmatch(line); match != nil {
+			current = &ObjectChange{
+				Namespace: match[1],
+				Name:      match[2],
+				Kind:      strings.TrimSpace(strings.SplitN(match[3], " (", 2)[0]),
+				Action:    helmAction(match[4]),
+			}
+			changes = append(changes, current)
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			current.Added++
+		case strings.HasPrefix(line, "-"):
+			current.Removed++
+		}
+	}
+	return changes
+}
+
+func helmAction(verb string) Action {
+	switch verb {
+	case "been added":
+		return ActionAdded
+	case "been removed":
+		return ActionRemoved
+	default:
+		return ActionChanged
+	}
+}
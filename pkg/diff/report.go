@@ -0,0 +1,205 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionReplace Action = "replace"
+	ActionDestroy Action = "destroy"
+
+	ActionAdded   Action = "added"
+	ActionChanged Action = "changed"
+	ActionRemoved Action = "removed"
+
+	TerraformSummary = "terraform.json"
+	HelmSummary      = "helm.json"
+)
+
+// ResourceChange is a terraform resource the plan would touch
+type ResourceChange struct {
+	Address    string             `json:"address"`
+	Type       string             `json:"type"`
+	Action     Action             `json:"action"`
+	Attributes []*AttributeChange `json:"attributes,omitempty"`
+}
+
+type AttributeChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ObjectChange is a kubernetes object helm would add, change or remove
+type ObjectChange struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Action    Action `json:"action"`
+	Added     int    `json:"lines_added"`
+	Removed   int    `json:"lines_removed"`
+	// Fields are the paths changed within the object, only known when diffing rendered manifests
+	Fields []string `json:"fields,omitempty"`
+}
+
+type RepoReport struct {
+	Repo      string            `json:"repo"`
+	Resources []*ResourceChange `json:"resources"`
+	Objects   []*ObjectChange   `json:"objects"`
+}
+
+type Report struct {
+	Repos []*RepoReport `json:"repos"`
+}
+
+// SummaryPath is where the structured summary of a tool's diff is kept, next to its raw output
+func SummaryPath(root, repo, name string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, "diffs", repo, name))
+}
+
+func WriteSummary(path string, summary interface{}) error {
+	contents, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0644)
+}
+
+// RemoveSummaries deletes the summaries a previous diff of repo left behind, so they can't be
+// reported again if this diff fails or skips the tool
+func RemoveSummaries(root, repo string, names ...string) error {
+	for _, name := range names {
+		if err := os.Remove(SummaryPath(root, repo, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadReport collects the named diff summaries written for repos.  Repos whose diff steps were
+// skipped have no summaries, and report no changes
+func LoadReport(root string, repos []string, names ...string) (*Report, error) {
+	report := &Report{Repos: make([]*RepoReport, 0, len(repos))}
+	for _, repo := range repos {
+		rr := &RepoReport{Repo: repo, Resources: []*ResourceChange{}, Objects: []*ObjectChange{}}
+		for _, name := range names {
+			var into interface{} = &rr.Objects
+			if name == TerraformSummary {
+				into = &rr.Resources
+			}
+			if err := readSummary(SummaryPath(root, repo, name), into); err != nil {
+				return nil, err
+			}
+		}
+		report.Repos = append(report.Repos, rr)
+	}
+	return report, nil
+}
+
+func readSummary(path string, into interface{}) error {
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, into)
+}
+
+func (r *Report) Render(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "md", "markdown":
+		_, err := io.WriteString(w, r.Markdown())
+		return err
+	default:
+		return fmt.Errorf("unsupported report format %s, must be one of md or json", format)
+	}
+}
+
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("## Plural diff\n")
+	for _, repo := range r.Repos {
+		fmt.Fprintf(&sb, "\n### %s\n\n", repo.Repo)
+		if len(repo.Resources) == 0 && len(repo.Objects) == 0 {
+			sb.WriteString("No changes\n")
+			continue
+		}
+
+		if len(repo.Resources) > 0 {
+			counts := map[Action]int{}
+			for _, res := range repo.Resources {
+				counts[res.Action]++
+			}
+			fmt.Fprintf(&sb, "**Terraform**: %d to create, %d to update, %d to replace, %d to destroy\n\n",
+				counts[ActionCreate], counts[ActionUpdate], counts[ActionReplace], counts[ActionDestroy])
+			sb.WriteString("| Action | Resource | Changed attributes |\n|---|---|---|\n")
+			for _, res := range repo.Resources {
+				attrs := make([]string, 0, len(res.Attributes))
+				for _, attr := range res.Attributes {
+					attrs = append(attrs, fmt.Sprintf("`%s`: `%s` → `%s`", attr.Name, mdEscape(attr.Before), mdEscape(attr.After)))
+				}
+				fmt.Fprintf(&sb, "| %s | `%s` | %s |\n", res.Action, res.Address, strings.Join(attrs, "<br>"))
+			}
+			sb.WriteString("\n")
+		}
+
+		if len(repo.Objects) > 0 {
+			counts := map[Action]int{}
+			for _, obj := range repo.Objects {
+				counts[obj.Action]++
+			}
+			fmt.Fprintf(&sb, "**Kubernetes**: %d added, %d changed, %d removed\n\n",
+				counts[ActionAdded], counts[ActionChanged], counts[ActionRemoved])
+			sb.WriteString("| Action | Kind | Namespace | Name | Changes |\n|---|---|---|---|---|\n")
+			for _, obj := range repo.Objects {
+				fmt.Fprintf(&sb, "| %s | %s | %s | %s | +%d -%d%s |\n", obj.Action, obj.Kind, obj.Namespace, obj.Name, obj.Added, obj.Removed, mdFields(obj.Fields))
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// mdFields lists the first few changed fields of an object
+func mdFields(fields []string) string {
+	const max = 10
+	if len(fields) == 0 {
+		return ""
+	}
+
+	shown := fields
+	if len(shown) > max {
+		shown = shown[:max]
+	}
+
+	var sb strings.Builder
+	for _, field := range shown {
+		fmt.Fprintf(&sb, "<br>`%s`", mdEscape(field))
+	}
+	if len(fields) > max {
+		fmt.Fprintf(&sb, "<br>and %d more", len(fields)-max)
+	}
+	return sb.String()
+}
+
+func mdEscape(val string) string {
+	return strings.NewReplacer("|", `\|`, "`", "'", "\n", " ").Replace(val)
+}
@@ -0,0 +1,172 @@
+package diff_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+const plan = `{
+  "format_version": "1.0",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.airflow",
+      "type": "aws_s3_bucket",
+      "change": {"actions": ["create"], "before": null, "after": {"bucket": "airflow"}}
+    },
+    {
+      "address": "aws_db_instance.airflow",
+      "type": "aws_db_instance",
+      "change": {
+        "actions": ["update"],
+        "before": {"instance_class": "db.t3.small", "password": "hunter2", "tags": {"env": "dev"}, "endpoint": "old"},
+        "after": {"instance_class": "db.t3.large", "password": "hunter3", "tags": {"env": "dev"}},
+        "after_unknown": {"endpoint": true},
+        "before_sensitive": {"password": true},
+        "after_sensitive": {"password": true}
+      }
+    },
+    {
+      "address": "kubernetes_secret.airflow",
+      "type": "kubernetes_secret",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"data": {"key": "old"}},
+        "after": {"data": {"key": "new"}},
+        "after_unknown": {},
+        "before_sensitive": {"data": {"key": true}},
+        "after_sensitive": {"data": {"key": true}}
+      }
+    },
+    {
+      "address": "helm_release.airflow",
+      "type": "helm_release",
+      "change": {
+        "actions": ["update"],
+        "before": {"set": [{"name": "db", "value": {"adminPassword": "hunter2"}}], "version": "1.0.0", "metadata": {"chart": "airflow"}},
+        "after": {"set": [{"name": "db", "value": {"adminPassword": "hunter3"}}], "version": "1.1.0", "metadata": {"chart": "airflow-2"}},
+        "after_unknown": {},
+        "before_sensitive": {},
+        "after_sensitive": {"metadata": [{"chart": true}]}
+      }
+    },
+    {
+      "address": "data.aws_region.current",
+      "type": "aws_region",
+      "change": {"actions": ["read"]}
+    },
+    {
+      "address": "aws_iam_role.old",
+      "type": "aws_iam_role",
+      "change": {"actions": ["delete"], "before": {"name": "old"}, "after": null}
+    }
+  ]
+}`
+
+const helmDiff = `airflow, airflow-web, Deployment (apps) has changed:
+  # Source: airflow/templates/web.yaml
+  spec:
+-   replicas: 1
++   replicas: 2
+airflow, airflow-creds, Secret (v1) has been added:
++ apiVersion: v1
++ kind: Secret
++ data:
++   password: aHVudGVyMg==
+airflow, airflow-statsd, ConfigMap (v1) has been removed:
+- apiVersion: v1
+`
+
+func TestParseTerraformPlan(t *testing.T) {
+	changes, err := diff.ParseTerraformPlan([]byte(plan))
+	assert.NoError(t, err)
+
+	actions := map[string]diff.Action{}
+	for _, change := range changes {
+		actions[change.Address] = change.Action
+	}
+	assert.Equal(t, map[string]diff.Action{
+		"aws_s3_bucket.airflow":     diff.ActionCreate,
+		"aws_db_instance.airflow":   diff.ActionUpdate,
+		"kubernetes_secret.airflow": diff.ActionReplace,
+		"helm_release.airflow":      diff.ActionUpdate,
+		"aws_iam_role.old":          diff.ActionDestroy,
+	}, actions)
+
+	assert.Equal(t, []*diff.AttributeChange{
+		{Name: "endpoint", Before: `"old"`, After: "(known after apply)"},
+		{Name: "instance_class", Before: `"db.t3.small"`, After: `"db.t3.large"`},
+		{Name: "password", Before: "(sensitive)", After: "(sensitive)"},
+	}, changes[1].Attributes)
+
+	assert.Equal(t, []*diff.AttributeChange{
+		{Name: "data", Before: "(sensitive)", After: "(sensitive)"},
+	}, changes[2].Attributes)
+
+	assert.Equal(t, []*diff.AttributeChange{
+		{Name: "metadata", Before: "(sensitive)", After: "(sensitive)"},
+		{Name: "set", Before: "(sensitive)", After: "(sensitive)"},
+		{Name: "version", Before: `"1.0.0"`, After: `"1.1.0"`},
+	}, changes[3].Attributes)
+}
+
+func TestParseHelmDiff(t *testing.T) {
+	objects := diff.ParseHelmDiff([]byte(helmDiff))
+	assert.Equal(t, []*diff.ObjectChange{
+		{Namespace: "airflow", Name: "airflow-web", Kind: "Deployment", Action: diff.ActionChanged, Added: 1, Removed: 1},
+		{Namespace: "airflow", Name: "airflow-creds", Kind: "Secret", Action: diff.ActionAdded, Added: 4},
+		{Namespace: "airflow", Name: "airflow-statsd", Kind: "ConfigMap", Action: diff.ActionRemoved, Removed: 1},
+	}, objects)
+}
+
+func TestReportMarkdown(t *testing.T) {
+	changes, err := diff.ParseTerraformPlan([]byte(plan))
+	assert.NoError(t, err)
+
+	report := &diff.Report{Repos: []*diff.RepoReport{
+		{Repo: "airflow", Resources: changes, Objects: diff.ParseHelmDiff([]byte(helmDiff))},
+		{Repo: "grafana"},
+	}}
+
+	md := report.Markdown()
+	assert.Contains(t, md, "**Terraform**: 1 to create, 2 to update, 1 to replace, 1 to destroy")
+	assert.Contains(t, md, "**Kubernetes**: 1 added, 1 changed, 1 removed")
+	assert.Contains(t, md, "| Secret | airflow | airflow-creds | +4 -0 |")
+	assert.Contains(t, md, "### grafana\n\nNo changes")
+	assert.NotContains(t, md, "hunter")
+	assert.NotContains(t, md, "aHVudGVyMg")
+}
+
+func TestLoadReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diffs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "diffs", "airflow"), 0755))
+	changes, err := diff.ParseTerraformPlan([]byte(plan))
+	assert.NoError(t, err)
+	assert.NoError(t, diff.WriteSummary(diff.SummaryPath(dir, "airflow", diff.TerraformSummary), changes))
+	assert.NoError(t, diff.WriteSummary(diff.SummaryPath(dir, "airflow", diff.HelmSummary), diff.ParseHelmDiff([]byte(helmDiff))))
+
+	report, err := diff.LoadReport(dir, []string{"airflow", "grafana"}, diff.TerraformSummary, diff.HelmSummary)
+	assert.NoError(t, err)
+	assert.Len(t, report.Repos[0].Resources, 5)
+	assert.Len(t, report.Repos[0].Objects, 3)
+	assert.Empty(t, report.Repos[1].Resources)
+
+	report, err = diff.LoadReport(dir, []string{"airflow"}, diff.HelmSummary)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Repos[0].Resources)
+	assert.Len(t, report.Repos[0].Objects, 3)
+
+	assert.NoError(t, diff.RemoveSummaries(dir, "airflow", diff.TerraformSummary, diff.HelmSummary))
+	assert.NoError(t, diff.RemoveSummaries(dir, "grafana", diff.TerraformSummary, diff.HelmSummary))
+	report, err = diff.LoadReport(dir, []string{"airflow"}, diff.TerraformSummary, diff.HelmSummary)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Repos[0].Resources)
+	assert.Empty(t, report.Repos[0].Objects)
+}
@@ -0,0 +1,181 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/crypto"
+	"github.com/pluralsh/plural/pkg/kubernetes"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"golang.org/x/mod/semver"
+)
+
+var versionPattern = regexp.MustCompile(`v?(\d+\.\d+\.\d+)`)
+
+// ParseVersion finds the first semantic version in a tool's version output, eg `Terraform v1.2.3`
+func ParseVersion(out string) string {
+	match := versionPattern.FindStringSubmatch(out)
+	if match == nil {
+		return ""
+	}
+	return "v" + match[1]
+}
+
+func output(command string, args ...string) (string, error) {
+	out, err := exec.Command(command, args...).CombinedOutput()
+	return strings.TrimSpace(string(out)), err
+}
+
+func toolCheck(tool, minimum, url string, args ...string) func() *Result {
+	return func() *Result {
+		if ok, _ := utils.Which(tool); !ok {
+			return Fail(fmt.Sprintf("install it from %s", url), "%s isn't installed", tool)
+		}
+
+		out, err := output(tool, args...)
+		version := ParseVersion(out)
+		if err != nil || version == "" {
+			return Warn(fmt.Sprintf("make sure `%s %s` works", tool, strings.Join(args, " ")), "couldn't tell which version of %s is installed", tool)
+		}
+
+		if semver.Compare(version, minimum) < 0 {
+			return Fail(fmt.Sprintf("upgrade it to %s or later from %s", minimum, url), "%s %s is older than plural supports", tool, version)
+		}
+		return Pass("%s %s", tool, version)
+	}
+}
+
+func checkRepository() *Result {
+	root, err := git.Toplevel()
+	if err != nil {
+		return Fail("clone your workspace repo and run plural from inside it", "not in a git repository")
+	}
+
+	if _, err := output("git", "rev-parse", "--abbrev-ref", "HEAD"); err != nil {
+		return Fail("commit a blank readme and push it to start working", "%s has no commits", root)
+	}
+
+	if remotes, err := output("git", "remote"); err != nil || remotes == "" {
+		return Warn("add the repo you push the workspace to with `git remote add origin URL`", "%s has no remotes", root)
+	}
+	return Pass("%s", root)
+}
+
+func checkFilters() *Result {
+	root, err := git.Toplevel()
+	if err != nil {
+		return Warn("run plural doctor from your workspace repo", "not in a git repository, so there are no git filters to check")
+	}
+
+	for _, conf := range crypto.GitFilters {
+		if val, _ := output("git", "config", "--get", conf[0]); val != conf[1] {
+			return Fail("run `plural crypto init` in the repo", "git config %s isn't set to `%s`, so secrets won't be encrypted", conf[0], conf[1])
+		}
+	}
+
+	attributes, err := utils.ReadFile(pathing.SanitizeFilepath(filepath.Join(root, ".gitattributes")))
+	if err != nil || !strings.Contains(attributes, "filter=plural-crypt") {
+		return Fail("run `plural crypto init` in the repo", ".gitattributes doesn't route secrets through the plural-crypt filter")
+	}
+	return Pass("plural-crypt filters are configured")
+}
+
+func checkEncryptionKey() *Result {
+	conf, err := crypto.ReadConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		return Warn("run `plural crypto init` in the repo", "there's no crypto.yml recording which key the repo is encrypted with")
+	}
+	if err != nil {
+		return Fail("fix or restore crypto.yml from git", "failed to read crypto.yml: %s", err)
+	}
+
+	hint := "import the repo's key with `plural crypto import`, or recover it from the cluster with `plural crypto recover`"
+	id, err := crypto.LocalId(conf)
+	if errors.Is(err, crypto.ErrNoKey) {
+		return Fail(hint, "there's no %s encryption key on this machine", conf.Type)
+	}
+	if err != nil {
+		return Fail(hint, "failed to read the local encryption key: %s", err)
+	}
+
+	if id != conf.Id {
+		return Fail(hint, "the local key's fingerprint %s doesn't match %s in crypto.yml", id, conf.Id)
+	}
+	return Pass("key fingerprint %s matches crypto.yml", id)
+}
+
+func checkLogin() *Result {
+	conf := config.Read()
+	if !config.Exists() || conf.Token == "" {
+		return Fail("run `plural login`", "not logged in to plural")
+	}
+
+	me, err := api.FromConfig(&conf).Me()
+	if err != nil {
+		return Fail("run `plural login` to get a new token", "the plural token for %s was rejected, it's likely expired: %s", conf.Email, err)
+	}
+	return Pass("logged in to %s as %s", conf.BaseUrl(), me.Email)
+}
+
+var cloudAuth = map[string]struct {
+	command []string
+	hint    string
+}{
+	provider.AWS:   {[]string{"aws", "sts", "get-caller-identity"}, "run `aws configure`, or `aws sso login` for sso profiles"},
+	provider.GCP:   {[]string{"gcloud", "auth", "print-access-token"}, "run `gcloud auth login` and `gcloud auth application-default login`"},
+	provider.AZURE: {[]string{"az", "account", "show"}, "run `az login`"},
+	provider.KIND:  {[]string{"docker", "info"}, "start docker, kind runs its clusters in containers"},
+}
+
+func checkCloudAuth(prov provider.Provider) *Result {
+	if prov.Name() == provider.EQUINIX {
+		if token, _ := prov.Context()["ApiToken"].(string); token == "" {
+			return Fail("set apiToken under context in workspace.yaml", "there's no equinix metal api token")
+		}
+		return Pass("equinix metal api token is set")
+	}
+
+	auth, ok := cloudAuth[prov.Name()]
+	if !ok {
+		return Warn("", "don't know how to check credentials for %s", prov.Name())
+	}
+
+	cli := auth.command[0]
+	if ok, _ := utils.Which(cli); !ok {
+		return Fail(fmt.Sprintf("install the %s cli", cli), "%s isn't installed", cli)
+	}
+	if out, err := output(cli, auth.command[1:]...); err != nil {
+		lines := strings.Split(out, "\n")
+		return Fail(auth.hint, "%s isn't authenticated: %s", cli, lines[len(lines)-1])
+	}
+	return Pass("%s is authenticated", cli)
+}
+
+func checkKubeContext(prov provider.Provider) *Result {
+	if kubernetes.InKubernetes() {
+		return Pass("running inside the cluster")
+	}
+
+	hint := "run `plural workspace kube-init` to point kubectl at the workspace's cluster"
+	current, err := output("kubectl", "config", "current-context")
+	if err != nil || current == "" {
+		return Fail(hint, "kubectl has no current context")
+	}
+
+	// providers name contexts differently, eg gke_project_region_cluster or arn:...:cluster/cluster,
+	// but they all include the cluster's name
+	if !strings.Contains(current, prov.Cluster()) {
+		return Warn(hint, "kube context %s doesn't look like cluster %s", current, prov.Cluster())
+	}
+	return Pass("kube context %s", current)
+}
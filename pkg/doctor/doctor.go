@@ -0,0 +1,124 @@
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Result is the outcome of a check, with a hint on how to fix anything that didn't pass
+type Result struct {
+	Check   string `json:"check"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Check diagnoses one thing plural needs to work
+type Check struct {
+	Name string
+	Run  func() *Result
+}
+
+func Pass(msg string, args ...interface{}) *Result {
+	return &Result{Status: StatusPass, Message: fmt.Sprintf(msg, args...)}
+}
+
+func Warn(hint, msg string, args ...interface{}) *Result {
+	return &Result{Status: StatusWarn, Message: fmt.Sprintf(msg, args...), Hint: hint}
+}
+
+func Fail(hint, msg string, args ...interface{}) *Result {
+	return &Result{Status: StatusFail, Message: fmt.Sprintf(msg, args...), Hint: hint}
+}
+
+// registry holds the checks that don't need the workspace's provider, in the order they're run
+var registry = []*Check{
+	{Name: "helm", Run: toolCheck("helm", "v3.0.0", "https://helm.sh/docs/intro/install", "version", "--template", "{{.Version}}")},
+	{Name: "kubectl", Run: toolCheck("kubectl", "v1.20.0", "https://kubernetes.io/docs/tasks/tools", "version", "--client", "--output=json")},
+	{Name: "terraform", Run: toolCheck("terraform", "v1.0.0", "https://www.terraform.io/downloads", "version")},
+	{Name: "git", Run: toolCheck("git", "v2.0.0", "https://git-scm.com/downloads", "--version")},
+	{Name: "git repository", Run: checkRepository},
+	{Name: "git filters", Run: checkFilters},
+	{Name: "encryption key", Run: checkEncryptionKey},
+	{Name: "plural login", Run: checkLogin},
+}
+
+// Diagnose runs every registered check, then sets up the workspace's provider and runs the checks
+// that need it
+func Diagnose() []*Result {
+	results := Run(registry)
+
+	prov, res := loadProvider()
+	res.Check = "workspace"
+	results = append(results, res)
+	if prov == nil {
+		return results
+	}
+
+	checks := []*Check{
+		{Name: "cloud credentials", Run: func() *Result { return checkCloudAuth(prov) }},
+		{Name: "kube context", Run: func() *Result { return checkKubeContext(prov) }},
+	}
+	return append(results, Run(append(checks, Preflights(prov)...))...)
+}
+
+func loadProvider() (provider.Provider, *Result) {
+	project, err := manifest.ReadProject(manifest.ProjectManifestPath())
+	if err != nil {
+		return nil, Warn("run `plural init` to set up a workspace, or run plural doctor from one", "no workspace.yaml, so the cloud and cluster checks were skipped")
+	}
+
+	prov, err := provider.FromManifest(project)
+	if err != nil {
+		return nil, Fail("check the provider settings in workspace.yaml", "failed to set up the %s provider: %s", project.Provider, err)
+	}
+	return prov, Pass("%s cluster %s", prov.Name(), prov.Cluster())
+}
+
+// Preflights adapts the provider's preflights, the ones `plural init` runs, into checks
+func Preflights(prov provider.Provider) []*Check {
+	checks := make([]*Check, 0)
+	for _, pre := range prov.Preflights() {
+		pre := pre
+		checks = append(checks, &Check{
+			Name: fmt.Sprintf("%s %s", prov.Name(), pre.Name),
+			Run: func() *Result {
+				if err := pre.Callback(); err != nil {
+					return Fail(fmt.Sprintf("fix the %s setup of your %s account, `plural preflights` reruns just these checks", pre.Name, prov.Name()), "%s", err)
+				}
+				return Pass("%s preflight passed", pre.Name)
+			},
+		})
+	}
+	return checks
+}
+
+// Run runs each check in order, labelling its result with the check's name
+func Run(checks []*Check) []*Result {
+	results := make([]*Result, 0, len(checks))
+	for _, check := range checks {
+		res := check.Run()
+		res.Check = check.Name
+		results = append(results, res)
+	}
+	return results
+}
+
+// Summarize counts the results with each status
+func Summarize(results []*Result) map[Status]int {
+	counts := map[Status]int{StatusPass: 0, StatusWarn: 0, StatusFail: 0}
+	for _, res := range results {
+		counts[res.Status]++
+	}
+	return counts
+}
@@ -0,0 +1,81 @@
+package doctor_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/doctor"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	provider.Provider
+	preflights []*provider.Preflight
+}
+
+func (f *fakeProvider) Name() string                      { return provider.GCP }
+func (f *fakeProvider) Preflights() []*provider.Preflight { return f.preflights }
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{
+			name:     `test helm versions`,
+			output:   "v3.9.4",
+			expected: "v3.9.4",
+		},
+		{
+			name:     `test terraform versions`,
+			output:   "Terraform v1.2.9\non linux_amd64",
+			expected: "v1.2.9",
+		},
+		{
+			name:     `test git versions`,
+			output:   "git version 2.37.1 (Apple Git-137.1)",
+			expected: "v2.37.1",
+		},
+		{
+			name:     `test kubectl json versions`,
+			output:   `{"clientVersion": {"major": "1", "minor": "25", "gitVersion": "v1.25.2"}, "kustomizeVersion": "v4.5.7"}`,
+			expected: "v1.25.2",
+		},
+		{
+			name:   `test output without a version`,
+			output: "command not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, doctor.ParseVersion(test.output))
+		})
+	}
+}
+
+func TestRunPreflights(t *testing.T) {
+	prov := &fakeProvider{preflights: []*provider.Preflight{
+		{Name: "Enabled Services", Callback: func() error { return nil }},
+		{Name: "Permissions", Callback: func() error { return fmt.Errorf("missing roles/owner") }},
+	}}
+
+	checks := append(doctor.Preflights(prov), &doctor.Check{
+		Name: "kube context",
+		Run: func() *doctor.Result {
+			return doctor.Warn("run `plural workspace kube-init`", "kube context %s doesn't look like cluster %s", "kind-dev", "prod")
+		},
+	})
+	results := doctor.Run(checks)
+
+	assert.Equal(t, []string{"google Enabled Services", "google Permissions", "kube context"}, []string{results[0].Check, results[1].Check, results[2].Check})
+	assert.Equal(t, doctor.StatusPass, results[0].Status)
+	assert.Equal(t, doctor.StatusFail, results[1].Status)
+	assert.Equal(t, "missing roles/owner", results[1].Message)
+	assert.NotEmpty(t, results[1].Hint)
+	assert.Equal(t, "kube context kind-dev doesn't look like cluster prod", results[2].Message)
+
+	assert.Equal(t, map[doctor.Status]int{doctor.StatusPass: 1, doctor.StatusWarn: 1, doctor.StatusFail: 1}, doctor.Summarize(results))
+}
@@ -26,7 +26,11 @@ func defaultSteps(path string) []*Step {
 			Command: "terraform",
 			Args:    []string{"apply", "-auto-approve"},
 			Sha:     "",
-			Retries: 2,
+			Retry: &RetryPolicy{
+				MaxAttempts: 3,
+				Backoff:     "10s",
+				Fatal:       []string{"Error acquiring the state lock"},
+			},
 		},
 		{
 			Name:    "terraform-output",
@@ -59,7 +63,7 @@ func defaultSteps(path string) []*Step {
 			Command: "plural",
 			Args:    []string{"wkspace", "helm", sanitizedPath},
 			Sha:     "",
-			Retries: 2,
+			Retry:   &RetryPolicy{MaxAttempts: 3, Backoff: "10s"},
 		},
 	}
 }
@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	StepStarted   EventType = "step_started"
+	StepSkipped   EventType = "step_skipped"
+	StepRetry     EventType = "step_retry"
+	StepFailed    EventType = "step_failed"
+	StepSucceeded EventType = "step_succeeded"
+)
+
+// Event is a single machine-readable record of step progress, emitted as one json object per line
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo,omitempty"`
+	Step     string    `json:"step"`
+	Command  string    `json:"command"`
+	Duration float64   `json:"duration,omitempty"`
+	OldSha   string    `json:"old_sha,omitempty"`
+	NewSha   string    `json:"new_sha,omitempty"`
+	Retries  int       `json:"retries_remaining,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Output   string    `json:"output,omitempty"`
+}
+
+var (
+	eventLock sync.Mutex
+	events    *json.Encoder
+)
+
+// EnableEvents streams an Event to w for every step transition from now on
+func EnableEvents(w io.Writer) {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	events = json.NewEncoder(w)
+}
+
+// DisableEvents stops streaming events
+func DisableEvents() {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	events = nil
+}
+
+func EventsEnabled() bool {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	return events != nil
+}
+
+// Emit writes event to the event stream, if enabled.  Failing to report progress never fails a step
+func Emit(event *Event) {
+	eventLock.Lock()
+	defer eventLock.Unlock()
+	if events == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	_ = events.Encode(event)
+}
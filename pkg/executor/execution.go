@@ -1,12 +1,17 @@
 package executor
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/hcl"
+	"github.com/pluralsh/plural/pkg/history"
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
@@ -16,6 +21,9 @@ import (
 type Execution struct {
 	Metadata Metadata `hcl:"metadata"`
 	Steps    []*Step  `hcl:"step"`
+
+	// Out is where deployment output is written, defaulting to stdout
+	Out io.Writer `hcle:"omit"`
 }
 
 type Metadata struct {
@@ -23,13 +31,41 @@ type Metadata struct {
 	Name string `hcl:"name"`
 }
 
-const (
-	pluralIgnore = `terraform/.terraform`
-)
+// defaultIgnore is always present in a repo's .pluralignore, alongside any entries users add.  The
+// lock file is listed on its own since under gitignore rules the directory entry doesn't match it, and
+// terraform init rewrites it
+var defaultIgnore = []string{"terraform/.terraform", "terraform/.terraform.lock.hcl"}
 
+// Ignore ensures the repo's .pluralignore contains the default entries, preserving anything else in it
 func Ignore(root string) error {
 	ignoreFile := pathing.SanitizeFilepath(filepath.Join(root, ".pluralignore"))
-	return ioutil.WriteFile(ignoreFile, []byte(pluralIgnore), 0644)
+	contents, err := ioutil.ReadFile(ignoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	existing := map[string]bool{}
+	lines := strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+	for _, line := range lines {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	if len(contents) == 0 {
+		lines = []string{}
+	}
+
+	missing := false
+	for _, entry := range defaultIgnore {
+		if !existing[entry] {
+			lines = append(lines, entry)
+			missing = true
+		}
+	}
+
+	if !missing {
+		return nil
+	}
+	return ioutil.WriteFile(ignoreFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
 }
 
 func GetExecution(path, name string) (*Execution, error) {
@@ -48,32 +84,64 @@ func GetExecution(path, name string) (*Execution, error) {
 	return &ex, nil
 }
 
-func (e *Execution) Execute(verbose bool) error {
+// Execute runs each stale step in order.  Cancelling ctx interrupts the running step and stops the
+// execution, always flushing the shas of completed steps so a rerun resumes where it left off.  Every
+// run is recorded in the repo's deploy history
+func (e *Execution) Execute(ctx context.Context, verbose bool) error {
 	root, err := git.Root()
 	if err != nil {
 		return err
 	}
 
+	out := e.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	entry := history.NewEntry(e.Metadata.Path)
+	err = e.execute(ctx, root, verbose, out, entry)
+	entry.Finish(err, ctx.Err() != nil)
+	if herr := history.Append(history.Dir(root, e.Metadata.Path), entry); herr != nil {
+		fmt.Fprintf(out, "could not record deploy history: %s\n", herr)
+	}
+
+	return err
+}
+
+func (e *Execution) execute(ctx context.Context, root string, verbose bool, out io.Writer, entry *history.Entry) error {
 	ignore, err := e.IgnoreFile(root)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("deploying %s.  This may take a while, so hold on to your butts\n", e.Metadata.Path)
+	fmt.Fprintf(out, "deploying %s.  This may take a while, so hold on to your butts\n", e.Metadata.Path)
 	for i, step := range e.Steps {
+		if err := ctx.Err(); err != nil {
+			if err := e.Flush(root); err != nil {
+				return err
+			}
+
+			return err
+		}
+
 		prev := step.Verbose
 		if verbose {
 			step.Verbose = true
 		}
+		step.Out = out
+		step.Repo = e.Metadata.Path
+		step.LogDir = LogDir(root, e.Metadata.Path)
 
-		newSha, err := step.Execute(root, ignore)
+		start := time.Now()
+		newSha, err := step.Execute(ctx, root, ignore)
 		step.Verbose = prev
+		entry.Step(step.Name, step.CommandLine(), start, err == nil && newSha == step.Sha, errorOutput(err), err)
 		if err != nil {
 			if err := e.Flush(root); err != nil {
 				return err
 			}
 
-			return err
+			return &StepError{Repo: e.Metadata.Path, Step: step.Name, Err: err}
 		}
 
 		e.Steps[i].Sha = newSha
@@ -101,7 +169,22 @@ func (e *Execution) IgnoreFile(root string) ([]string, error) {
 	return result, nil
 }
 
-func DefaultExecution(path string, prev *Execution) (e *Execution) {
+// Inherit carries over the state of a previous build of the step, its sha along with the settings
+// users may have edited, so rebuilding the default steps doesn't erase them
+func (step *Step) Inherit(prev *Step) {
+	step.Sha = prev.Sha
+	step.Ignore = prev.Ignore
+	if prev.Retry != nil {
+		step.Retry = prev.Retry
+	}
+	if prev.Timeout != "" {
+		step.Timeout = prev.Timeout
+	}
+}
+
+// DefaultExecution merges the default deploy steps into the previous deploy.hcl, keeping the order of
+// both.  It fails, naming the steps, if the two orders contradict each other
+func DefaultExecution(path string, prev *Execution) (*Execution, error) {
 	byName := make(map[string]*Step)
 	steps := defaultSteps(path)
 
@@ -110,9 +193,8 @@ func DefaultExecution(path string, prev *Execution) (e *Execution) {
 	}
 
 	for _, step := range steps {
-		prev, ok := byName[step.Name]
-		if ok {
-			step.Sha = prev.Sha
+		if prev, ok := byName[step.Name]; ok {
+			step.Inherit(prev)
 		}
 		byName[step.Name] = step
 	}
@@ -134,7 +216,7 @@ func DefaultExecution(path string, prev *Execution) (e *Execution) {
 	finalizedSteps := []*Step{}
 	sorted, ok := graph.Topsort()
 	if !ok {
-		panic("deployfile cycle detected")
+		return nil, fmt.Errorf("the order of steps %s in %s/deploy.hcl conflicts with the default order, reorder or remove them", utils.FormatCycle(graph.Cycle()), path)
 	}
 
 	// dump the topsort to a list and use that from now on
@@ -145,7 +227,7 @@ func DefaultExecution(path string, prev *Execution) (e *Execution) {
 	return &Execution{
 		Metadata: Metadata{Path: path, Name: "deploy"},
 		Steps:    finalizedSteps,
-	}
+	}, nil
 }
 
 func (e *Execution) Flush(root string) error {
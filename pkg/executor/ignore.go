@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ignoreMatcher applies .pluralignore entries with gitignore semantics: globs, `**`, trailing
+// slashes for directories and `!` negation, with later entries taking precedence.  Paths are
+// matched relative to the parent of the hashed target, which is the repo directory for every
+// target plural generates
+type ignoreMatcher struct {
+	matcher gitignore.Matcher
+}
+
+func newIgnoreMatcher(lines []string) *ignoreMatcher {
+	patterns := make([]gitignore.Pattern, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, gitignore.ParsePattern(strings.TrimPrefix(line, `\`), nil))
+	}
+
+	return &ignoreMatcher{matcher: gitignore.NewMatcher(patterns)}
+}
+
+func (m *ignoreMatcher) ignored(file string) bool {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(file), "/"), "/")
+	return m.matcher.Match(parts, false)
+}
+
+// ignoreFor combines the repo's .pluralignore entries with the step's own, which come last so
+// they can also re-include files with negation
+func (step Step) ignoreFor(ignore []string) []string {
+	if len(step.Ignore) == 0 {
+		return ignore
+	}
+
+	result := make([]string, 0, len(ignore)+len(step.Ignore))
+	result = append(result, ignore...)
+	return append(result, step.Ignore...)
+}
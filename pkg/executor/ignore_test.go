@@ -0,0 +1,133 @@
+package executor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoredFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		ignore  []string
+		modify  string
+		changed bool
+	}{
+		{
+			name:    `test unignored file changes the hash`,
+			ignore:  []string{"helm/.terraform"},
+			modify:  "helm/values.yaml",
+			changed: true,
+		},
+		{
+			name:   `test legacy prefix entries are still ignored`,
+			ignore: []string{"helm/.terraform"},
+			modify: "helm/.terraform/providers.lock",
+		},
+		{
+			name:   `test glob matches at any depth`,
+			ignore: []string{"*.bak"},
+			modify: "helm/app/templates/deployment.yaml.bak",
+		},
+		{
+			name:   `test double star matches nested directories`,
+			ignore: []string{"helm/**/charts/"},
+			modify: "helm/app/charts/postgres.tgz",
+		},
+		{
+			name:    `test negation re-includes files`,
+			ignore:  []string{"*.bak", "!important.bak"},
+			modify:  "helm/important.bak",
+			changed: true,
+		},
+		{
+			name:    `test comments are not patterns`,
+			ignore:  []string{"# values.yaml"},
+			modify:  "helm/values.yaml",
+			changed: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "executor")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			files := []string{
+				"helm/values.yaml",
+				"helm/.terraform/providers.lock",
+				"helm/app/templates/deployment.yaml.bak",
+				"helm/app/charts/postgres.tgz",
+				"helm/important.bak",
+			}
+			for _, file := range files {
+				path := filepath.Join(dir, file)
+				assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				assert.NoError(t, ioutil.WriteFile(path, []byte("original"), 0644))
+			}
+
+			target := filepath.Join(dir, "helm")
+			before, err := executor.MkHash(target, test.ignore)
+			assert.NoError(t, err)
+
+			err = ioutil.WriteFile(filepath.Join(dir, test.modify), []byte("modified"), 0644)
+			assert.NoError(t, err)
+
+			after, err := executor.MkHash(target, test.ignore)
+			assert.NoError(t, err)
+			assert.Equal(t, test.changed, before != after)
+		})
+	}
+}
+
+func TestIgnorePreservesEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".pluralignore")
+	err = ioutil.WriteFile(path, []byte("# generated charts\nhelm/**/charts/\n*.bak\n"), 0644)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, executor.Ignore(dir))
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "# generated charts\nhelm/**/charts/\n*.bak\nterraform/.terraform\nterraform/.terraform.lock.hcl\n", string(contents))
+}
+
+func TestDefaultIgnoreSkipsTerraformState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, file := range []string{"terraform/main.tf", "terraform/.terraform.lock.hcl", "terraform/.terraform/providers/aws"} {
+		path := filepath.Join(dir, file)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte("original"), 0644))
+	}
+	assert.NoError(t, executor.Ignore(dir))
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, ".pluralignore"))
+	assert.NoError(t, err)
+	ignore := strings.Split(strings.TrimSpace(string(contents)), "\n")
+
+	target := filepath.Join(dir, "terraform")
+	before, err := executor.MkHash(target, ignore)
+	assert.NoError(t, err)
+
+	// terraform init rewrites the lock file and provider cache without changing the module
+	for _, file := range []string{"terraform/.terraform.lock.hcl", "terraform/.terraform/providers/aws"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, file), []byte("reinitialized"), 0644))
+	}
+	after, err := executor.MkHash(target, ignore)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// MaxStepLogs is how many runs of each step keep their logs around
+var MaxStepLogs = 10
+
+const (
+	logTimeFormat = "20060102T150405.000"
+	logFooter     = "--- plural step finished: "
+	followPoll    = 500 * time.Millisecond
+)
+
+// LogDir is where the step logs of a repo are kept, git-ignored
+func LogDir(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, ".plural", "logs"))
+}
+
+// StepLogs lists the log files for runs of step in dir, oldest first
+func StepLogs(dir, step string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := regexp.MustCompile(fmt.Sprintf(`^%s-\d{8}T\d{6}\.\d{3}\.log$`, regexp.QuoteMeta(step)))
+	logs := make([]string, 0)
+	for _, f := range files {
+		if !f.IsDir() && r.MatchString(f.Name()) {
+			logs = append(logs, pathing.SanitizeFilepath(filepath.Join(dir, f.Name())))
+		}
+	}
+
+	// the timestamp suffix sorts chronologically
+	sort.Strings(logs)
+	return logs, nil
+}
+
+// openStepLog creates the log for a new run of step, pruning the oldest logs beyond MaxStepLogs
+func openStepLog(dir, step string) (*os.File, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.log", step, time.Now().Format(logTimeFormat))
+	f, err := os.Create(pathing.SanitizeFilepath(filepath.Join(dir, name)))
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := StepLogs(dir, step)
+	if err != nil {
+		return f, nil
+	}
+
+	for i := 0; i < len(logs)-MaxStepLogs; i++ {
+		_ = os.Remove(logs[i])
+	}
+	return f, nil
+}
+
+func closeStepLog(f *os.File, err error) {
+	status := "succeeded"
+	if err != nil {
+		status = fmt.Sprintf("failed (%s)", err)
+	}
+
+	fmt.Fprintf(f, "\n%s%s at %s\n", logFooter, status, time.Now().Format(time.RFC3339))
+	_ = f.Close()
+}
+
+// FollowLog copies the log at path to w, then keeps polling for new output until the step
+// finishes or ctx is done
+func FollowLog(ctx context.Context, path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tail := make([]byte, 0)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+
+			tail = append(tail, buf[:n]...)
+			if len(tail) > 1024 {
+				tail = tail[len(tail)-1024:]
+			}
+		}
+
+		if err != nil && err != io.EOF { //nolint:errorlint
+			return err
+		}
+
+		if n > 0 {
+			continue
+		}
+
+		if bytes.Contains(tail, []byte(logFooter)) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followPoll):
+		}
+	}
+}
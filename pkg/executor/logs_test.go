@@ -0,0 +1,90 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		args     []string
+		runs     int
+		expected string
+	}{
+		{
+			name:     `test logs capture command output`,
+			command:  "echo",
+			args:     []string{"hello"},
+			runs:     1,
+			expected: "hello",
+		},
+		{
+			name:     `test old logs are rotated away`,
+			command:  "echo",
+			args:     []string{"rotated"},
+			runs:     4,
+			expected: "rotated",
+		},
+		{
+			name:     `test failures are logged`,
+			command:  "sh",
+			args:     []string{"-c", "echo broken; exit 1"},
+			runs:     1,
+			expected: "broken",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "executor")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			prev := executor.MaxStepLogs
+			executor.MaxStepLogs = 2
+			defer func() { executor.MaxStepLogs = prev }()
+
+			logDir := filepath.Join(dir, "logs")
+			step := executor.Step{Name: "test", Wkdir: ".", Target: ".", Command: test.command, Args: test.args, LogDir: logDir, Out: ioutil.Discard}
+			for i := 0; i < test.runs; i++ {
+				_ = step.Attempt(context.Background(), dir)
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			files, err := executor.StepLogs(logDir, "test")
+			assert.NoError(t, err)
+			expected := test.runs
+			if expected > executor.MaxStepLogs {
+				expected = executor.MaxStepLogs
+			}
+			assert.Equal(t, expected, len(files))
+
+			var buf bytes.Buffer
+			err = executor.FollowLog(context.Background(), files[len(files)-1], &buf)
+			assert.NoError(t, err)
+			assert.Contains(t, buf.String(), test.expected)
+			assert.Contains(t, buf.String(), "plural step finished")
+		})
+	}
+}
+
+func TestStepLogsSuppressDots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	step := executor.Step{Name: "test", Wkdir: ".", Target: ".", Command: "sh", Args: []string{"-c", "echo one; echo two"}, LogDir: filepath.Join(dir, "logs"), Out: &out}
+	err = step.Attempt(context.Background(), dir)
+	assert.NoError(t, err)
+	assert.NotContains(t, out.String(), ".")
+}
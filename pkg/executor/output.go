@@ -1,14 +1,18 @@
 package executor
 
 import (
+	"bytes"
 	"io"
 	"strings"
+	"sync"
 )
 
 type OutputWriter struct {
-	delegate    io.WriteCloser
+	delegate    io.Writer
 	useDelegate bool
-	lines       []string
+	// quiet collects lines without printing progress dots, for when the output is already going to a log
+	quiet bool
+	lines []string
 }
 
 func (out *OutputWriter) Write(line []byte) (int, error) {
@@ -17,6 +21,10 @@ func (out *OutputWriter) Write(line []byte) (int, error) {
 	}
 
 	out.lines = append(out.lines, string(line))
+	if out.quiet {
+		return len(line), nil
+	}
+
 	_, err := out.delegate.Write([]byte("."))
 	if err != nil {
 		return 0, err
@@ -25,9 +33,58 @@ func (out *OutputWriter) Write(line []byte) (int, error) {
 }
 
 func (out *OutputWriter) Close() error {
-	return out.delegate.Close()
+	if closer, ok := out.delegate.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
 }
 
 func (out *OutputWriter) Format() string {
 	return strings.Join(out.lines, "")
 }
+
+// PrefixWriter tags every line with a prefix and serializes complete lines onto a shared
+// delegate, so concurrent executions writing to the same terminal stay readable
+type PrefixWriter struct {
+	prefix   string
+	delegate io.Writer
+	lock     *sync.Mutex
+	buf      []byte
+}
+
+func NewPrefixWriter(delegate io.Writer, lock *sync.Mutex, prefix string) *PrefixWriter {
+	return &PrefixWriter{prefix: prefix, delegate: delegate, lock: lock}
+}
+
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		ind := bytes.IndexByte(pw.buf, '\n')
+		if ind < 0 {
+			return len(p), nil
+		}
+
+		if err := pw.writeLine(pw.buf[:ind+1]); err != nil {
+			return 0, err
+		}
+		pw.buf = pw.buf[ind+1:]
+	}
+}
+
+// Flush writes out any trailing partial line
+func (pw *PrefixWriter) Flush() error {
+	if len(pw.buf) == 0 {
+		return nil
+	}
+
+	line := append(pw.buf, '\n')
+	pw.buf = nil
+	return pw.writeLine(line)
+}
+
+func (pw *PrefixWriter) writeLine(line []byte) error {
+	pw.lock.Lock()
+	defer pw.lock.Unlock()
+	_, err := pw.delegate.Write(append([]byte(pw.prefix), line...))
+	return err
+}
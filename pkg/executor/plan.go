@@ -0,0 +1,78 @@
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// StepPlan records whether a step would run on the next execution, without running it
+type StepPlan struct {
+	Step  *Step
+	Sha   string
+	Stale bool
+	// Changed holds the locally modified files under the step's target, as reported by git
+	Changed []string
+}
+
+func (p *StepPlan) Reason() string {
+	switch {
+	case !p.Stale:
+		return "no changes"
+	case p.Step.Sha == "":
+		return "never run"
+	case len(p.Changed) > 0:
+		return "modified files in " + p.Step.Target
+	default:
+		return p.Step.Target + " differs from the last run"
+	}
+}
+
+// Plan computes the current hash of every step target and compares it against the recorded sha,
+// the same way Execute decides whether a step needs to run
+func (e *Execution) Plan() ([]*StepPlan, error) {
+	root, err := git.Root()
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := e.IgnoreFile(root)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := git.Modified()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*StepPlan, 0, len(e.Steps))
+	for _, step := range e.Steps {
+		current, err := MkHash(pathing.SanitizeFilepath(filepath.Join(root, step.Target)), step.ignoreFor(ignore))
+		if err != nil {
+			return nil, err
+		}
+
+		plan := &StepPlan{Step: step, Sha: current, Stale: current != step.Sha}
+		if plan.Stale {
+			plan.Changed = changedUnder(modified, step.Target)
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+func changedUnder(modified []string, target string) []string {
+	// git always reports paths with forward slashes
+	prefix := filepath.ToSlash(target)
+	result := make([]string, 0)
+	for _, file := range modified {
+		if file == prefix || strings.HasPrefix(file, strings.TrimSuffix(prefix, "/")+"/") {
+			result = append(result, file)
+		}
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// GracePeriod is how long an interrupted child process has to exit before it is killed
+var GracePeriod = 1 * time.Minute
+
+// RunContext runs cmd until it exits or ctx is done.  On cancellation the child's process group is
+// first sent the received shutdown signal (SIGINT on timeouts), and only killed if the child outlives
+// the grace period
+func RunContext(ctx context.Context, cmd *exec.Cmd) error {
+	if ctx.Done() == nil {
+		return cmd.Run()
+	}
+
+	isolate(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	if err := signalGroup(cmd, interruptSignal(ctx)); err != nil {
+		killGroup(cmd)
+	}
+
+	timer := time.NewTimer(GracePeriod)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	case <-forceKill(ctx):
+	}
+
+	killGroup(cmd)
+	<-done
+	return fmt.Errorf("%s was killed after being interrupted", cmd.String())
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolate moves the child into its own process group, so a terminal ctrl-c only reaches plural,
+// which then forwards exactly one signal instead of the child receiving it twice
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalGroup sends sig to the child's whole process group, since the group is no longer the
+// terminal's and the helm or terraform a plural subcommand starts would otherwise never see it
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+// killGroup kills the child along with anything it started, so nothing is orphaned holding state locks
+func killGroup(cmd *exec.Cmd) {
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,56 @@
+//go:build !windows
+
+package executor_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunContextInterruptsGrandchildren(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	grace := executor.GracePeriod
+	executor.GracePeriod = 5 * time.Second
+	defer func() { executor.GracePeriod = grace }()
+
+	// the inner shell execs into a sleep, like plural wkspace running helm or terraform
+	pidfile := filepath.Join(dir, "pid")
+	cmd := exec.Command("sh", "-c", `sh -c "echo \$\$ > `+pidfile+`; exec sleep 30"`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- executor.RunContext(ctx, cmd) }()
+
+	var pid int
+	assert.Eventually(t, func() bool {
+		contents, err := ioutil.ReadFile(pidfile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(contents)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	assert.Error(t, <-done)
+	assert.Less(t, time.Since(start), executor.GracePeriod)
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) == syscall.ESRCH
+	}, 5*time.Second, 10*time.Millisecond)
+}
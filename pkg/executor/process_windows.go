@@ -0,0 +1,16 @@
+package executor
+
+import (
+	"os"
+	"os/exec"
+)
+
+func isolate(cmd *exec.Cmd) {}
+
+func signalGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
+
+func killGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
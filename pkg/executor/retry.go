@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy controls how a failed step is retried, and is configured with a retry block in the step
+//
+//	retry {
+//	  max_attempts = 3
+//	  backoff      = "10s"
+//	  max_backoff  = "2m"
+//	  fatal        = ["Error acquiring the state lock"]
+//	}
+type RetryPolicy struct {
+	// MaxAttempts is the total number of runs, including the first
+	MaxAttempts int `hcl:"max_attempts"`
+	// Backoff is the delay before the first retry, doubling with each further attempt
+	Backoff    string `hcl:"backoff" hcle:"omitempty"`
+	MaxBackoff string `hcl:"max_backoff" hcle:"omitempty"`
+	// Retryable, when set, restricts retries to failures whose output matches one of these regexes
+	Retryable []string `hcl:"retryable" hcle:"omitempty"`
+	// Fatal failures, whose output matches one of these regexes, are never retried
+	Fatal []string `hcl:"fatal" hcle:"omitempty"`
+}
+
+const defaultMaxBackoff = 5 * time.Minute
+
+// RetryPolicy returns the effective policy for the step, falling back to the legacy retries count
+func (step Step) RetryPolicy() *RetryPolicy {
+	if step.Retry != nil {
+		return step.Retry
+	}
+
+	return &RetryPolicy{MaxAttempts: step.Retries + 1}
+}
+
+func (p *RetryPolicy) Validate() error {
+	for _, dur := range []string{p.Backoff, p.MaxBackoff} {
+		if dur == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(dur); err != nil {
+			return fmt.Errorf("invalid backoff %q: %w", dur, err)
+		}
+	}
+
+	for _, pattern := range append(append([]string{}, p.Retryable...), p.Fatal...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid output pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (p *RetryPolicy) Attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// ShouldRetry classifies a failure by its captured output.  Failures without output, eg from
+// verbose runs, can't be classified and are always retryable
+func (p *RetryPolicy) ShouldRetry(output string) bool {
+	if output == "" {
+		return true
+	}
+
+	if matchesAny(p.Fatal, output) {
+		return false
+	}
+
+	return len(p.Retryable) == 0 || matchesAny(p.Retryable, output)
+}
+
+// Delay is the backoff before the given retry, starting at 1, with up to half of it jittered away
+// so concurrent deploys don't retry in lockstep
+func (p *RetryPolicy) Delay(retry int) time.Duration {
+	base, _ := time.ParseDuration(p.Backoff)
+	if base <= 0 {
+		return 0
+	}
+
+	max := defaultMaxBackoff
+	if p.MaxBackoff != "" {
+		max, _ = time.ParseDuration(p.MaxBackoff)
+	}
+
+	delay := base
+	for i := 1; i < retry && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func matchesAny(patterns []string, output string) bool {
+	for _, pattern := range patterns {
+		if r, err := regexp.Compile(pattern); err == nil && r.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package executor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := &executor.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     "10s",
+		MaxBackoff:  "30s",
+		Retryable:   []string{"timeout", "connection reset"},
+		Fatal:       []string{"Error acquiring the state lock"},
+	}
+	assert.NoError(t, policy.Validate())
+
+	assert.True(t, policy.ShouldRetry("dial tcp: i/o timeout"))
+	assert.False(t, policy.ShouldRetry("Error acquiring the state lock: timeout"))
+	assert.False(t, policy.ShouldRetry("release airflow not found"))
+	assert.True(t, policy.ShouldRetry(""))
+
+	for retry, max := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 5: 30 * time.Second} {
+		delay := policy.Delay(retry)
+		assert.LessOrEqual(t, delay, max)
+		assert.GreaterOrEqual(t, delay, max/2)
+	}
+
+	invalid := &executor.RetryPolicy{Fatal: []string{"("}}
+	assert.Error(t, invalid.Validate())
+}
+
+func TestRetryPolicyRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = os.MkdirAll(filepath.Join(dir, "app"), 0755)
+	assert.NoError(t, err)
+
+	execution, err := executor.DefaultExecution("app", &executor.Execution{})
+	assert.NoError(t, err)
+	assert.NoError(t, execution.Flush(dir))
+
+	read, err := executor.GetExecution(filepath.Join(dir, "app"), "deploy")
+	assert.NoError(t, err)
+	for i, step := range read.Steps {
+		assert.Equal(t, execution.Steps[i].RetryPolicy(), step.RetryPolicy())
+	}
+}
+
+func TestDefaultExecutionConflict(t *testing.T) {
+	prev := &executor.Execution{Steps: []*executor.Step{{Name: "terraform-apply"}, {Name: "terraform-init"}}}
+	_, err := executor.DefaultExecution("app", prev)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "terraform-apply -> terraform-init -> terraform-apply")
+}
+
+func TestStepSettingsSurviveRebuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "app"), 0755))
+
+	execution, err := executor.DefaultExecution("app", &executor.Execution{})
+	assert.NoError(t, err)
+	assert.NoError(t, execution.Flush(dir))
+
+	edited, err := executor.GetExecution(filepath.Join(dir, "app"), "deploy")
+	assert.NoError(t, err)
+	policy := &executor.RetryPolicy{MaxAttempts: 5, Backoff: "1m", Retryable: []string{"rate limit"}}
+	edited.Steps[0].Retry = policy
+	edited.Steps[0].Timeout = "45m"
+	assert.NoError(t, edited.Flush(dir))
+
+	prev, err := executor.GetExecution(filepath.Join(dir, "app"), "deploy")
+	assert.NoError(t, err)
+	rebuilt, err := executor.DefaultExecution("app", prev)
+	assert.NoError(t, err)
+	assert.NoError(t, rebuilt.Flush(dir))
+
+	read, err := executor.GetExecution(filepath.Join(dir, "app"), "deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, policy, read.Steps[0].Retry)
+	assert.Equal(t, "45m", read.Steps[0].Timeout)
+	for i, step := range read.Steps[1:] {
+		assert.Equal(t, execution.Steps[i+1].RetryPolicy(), step.RetryPolicy())
+	}
+}
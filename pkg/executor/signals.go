@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+type interruptKey struct{}
+
+// interrupt remembers the first shutdown signal received, so running steps can forward it to their
+// child, and whether a second one arrived demanding an immediate kill
+type interrupt struct {
+	mu     sync.Mutex
+	signal os.Signal
+	force  chan struct{}
+}
+
+// NotifyContext returns a context cancelled on SIGINT or SIGTERM.  Steps running under it forward
+// the signal to their child process, and a second signal kills the child without a grace period
+func NotifyContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	intr := &interrupt{force: make(chan struct{})}
+	ctx = context.WithValue(ctx, interruptKey{}, intr)
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			intr.mu.Lock()
+			intr.signal = sig
+			intr.mu.Unlock()
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+
+		if _, ok := <-sigs; ok {
+			close(intr.force)
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(sigs)
+		cancel()
+	}
+}
+
+func interruptSignal(ctx context.Context) os.Signal {
+	if intr, ok := ctx.Value(interruptKey{}).(*interrupt); ok {
+		intr.mu.Lock()
+		defer intr.mu.Unlock()
+		if intr.signal != nil {
+			return intr.signal
+		}
+	}
+	return os.Interrupt
+}
+
+func forceKill(ctx context.Context) <-chan struct{} {
+	if intr, ok := ctx.Value(interruptKey{}).(*interrupt); ok {
+		return intr.force
+	}
+	return nil
+}
@@ -1,12 +1,15 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
@@ -22,6 +25,25 @@ func (we *WrappedError) Error() string {
 	return we.inner.Error()
 }
 
+func (we *WrappedError) Unwrap() error {
+	return we.inner
+}
+
+// StepError identifies the repo and step a deploy failed in, while reading as the underlying error
+type StepError struct {
+	Repo string
+	Step string
+	Err  error
+}
+
+func (se *StepError) Error() string {
+	return se.Err.Error()
+}
+
+func (se *StepError) Unwrap() error {
+	return se.Err
+}
+
 type Step struct {
 	Name    string   `hcl:",key"`
 	Wkdir   string   `hcl:"wkdir"`
@@ -31,69 +53,254 @@ type Step struct {
 	Sha     string   `hcl:"sha"`
 	Retries int      `hcl:"retries"`
 	Verbose bool     `hcl:"verbose"`
+	// Retry supersedes Retries with backoff and output based classification of failures
+	Retry *RetryPolicy `hcl:"retry" hcle:"omitempty"`
+	// Timeout is an optional duration, like "30m", after which the step is interrupted
+	Timeout string `hcl:"timeout" hcle:"omitempty"`
+	// Ignore holds extra .pluralignore entries applied only when hashing this step's target
+	Ignore []string `hcl:"ignore" hcle:"omitempty"`
+
+	// Out is where step output is written, defaulting to stdout
+	Out io.Writer `hcle:"omit"`
+	// Repo is the repo the step belongs to, used when reporting events
+	Repo string `hcle:"omit"`
+	// LogDir, when set, is where the full output of every run of the step is logged
+	LogDir string `hcle:"omit"`
 }
 
 func SuppressedCommand(command string, args ...string) (cmd *exec.Cmd, output *OutputWriter) {
+	return suppressedCommand(os.Stdout, command, args...)
+}
+
+func suppressedCommand(out io.Writer, command string, args ...string) (cmd *exec.Cmd, output *OutputWriter) {
 	cmd = exec.Command(command, args...)
-	output = &OutputWriter{delegate: os.Stdout}
+	output = &OutputWriter{delegate: out}
 	cmd.Stdout = output
 	cmd.Stderr = output
 	return
 }
 
 func RunCommand(cmd *exec.Cmd, output *OutputWriter) (err error) {
-	err = cmd.Run()
+	return runCommand(context.Background(), cmd, output)
+}
+
+func runCommand(ctx context.Context, cmd *exec.Cmd, output *OutputWriter) (err error) {
+	err = RunContext(ctx, cmd)
 	if err != nil {
 		out := output.Format()
-		fmt.Printf("\nOutput:\n\n%s\n", out)
+		fmt.Fprintf(output.delegate, "\nOutput:\n\n%s\n", out)
 		err = &WrappedError{inner: err, Output: out}
 		return
 	}
 
-	utils.Success("\u2713\n")
+	utils.SuccessTo(output.delegate, "\u2713\n")
 	return
 }
 
-func (step Step) Run(root string) error {
+func (step Step) out() io.Writer {
+	if step.Out == nil {
+		return os.Stdout
+	}
+	return step.Out
+}
+
+func (step Step) Run(ctx context.Context, root string) error {
+	return step.run(ctx, root, nil)
+}
+
+// run executes the command once, additionally teeing all of its output to log if present
+func (step Step) run(ctx context.Context, root string, log io.Writer) error {
 	dir := pathing.SanitizeFilepath(filepath.Join(root, step.Wkdir))
+	out := step.out()
 	if step.Verbose && os.Getenv("ENABLE_COLOR") == "" {
 		cmd := exec.Command(step.Command, step.Args...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stdout
+		cmd.Stdout = tee(out, log)
+		cmd.Stderr = cmd.Stdout
 		cmd.Dir = dir
-		fmt.Println()
-		return cmd.Run()
+		fmt.Fprintln(out)
+		return RunContext(ctx, cmd)
 	}
 
-	cmd, output := SuppressedCommand(step.Command, step.Args...)
+	cmd, output := suppressedCommand(out, step.Command, step.Args...)
+	output.quiet = log != nil
+	cmd.Stdout = tee(output, log)
+	cmd.Stderr = cmd.Stdout
 	cmd.Dir = dir
-	return RunCommand(cmd, output)
+	return runCommand(ctx, cmd, output)
 }
 
-func (step Step) Execute(root string, ignore []string) (string, error) {
-	current, err := MkHash(pathing.SanitizeFilepath(filepath.Join(root, step.Target)), ignore)
+func tee(w, log io.Writer) io.Writer {
+	if log == nil {
+		return w
+	}
+	return io.MultiWriter(w, log)
+}
+
+// Execute runs the step if its target has changed since the recorded sha, returning the new sha.
+// Cancelling ctx interrupts the running command and returns without retrying
+func (step Step) Execute(ctx context.Context, root string, ignore []string) (string, error) {
+	current, err := MkHash(pathing.SanitizeFilepath(filepath.Join(root, step.Target)), step.ignoreFor(ignore))
 	if err != nil {
 		return step.Sha, err
 	}
 
-	utils.Highlight("%s %s ~> ", step.Command, strings.Join(step.Args, " "))
+	out := step.out()
 	if current == step.Sha {
-		utils.Success("no changes to be made for %s\n", step.Name)
+		utils.HighlightTo(out, "%s %s ~> ", step.Command, strings.Join(step.Args, " "))
+		utils.SuccessTo(out, "no changes to be made for %s\n", step.Name)
+		Emit(step.event(StepSkipped, current))
 		return current, nil
 	}
 
-	err = step.Run(root)
+	if err := step.attempt(ctx, root, current); err != nil {
+		return step.Sha, err
+	}
+	return current, nil
+}
+
+// Attempt runs the step's command regardless of its target, retrying according to its retry policy
+func (step Step) Attempt(ctx context.Context, root string) error {
+	return step.attempt(ctx, root, "")
+}
+
+func (step Step) attempt(ctx context.Context, root, sha string) error {
+	timeout, err := step.timeout()
 	if err != nil {
-		if step.Retries > 0 {
-			step.Retries -= 1
-			fmt.Printf("retrying command, number of retries remaining: %d\n", step.Retries)
-			return step.Execute(root, ignore)
+		return err
+	}
+
+	policy := step.RetryPolicy()
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policy for step %s: %w", step.Name, err)
+	}
+
+	var log *os.File
+	if step.LogDir != "" {
+		if log, err = openStepLog(step.LogDir, step.Name); err != nil {
+			return err
 		}
+		defer func() { closeStepLog(log, err) }()
+	}
 
-		return step.Sha, err
+	out := step.out()
+	Emit(step.event(StepStarted, sha))
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		utils.HighlightTo(out, "%s %s ~> ", step.Command, strings.Join(step.Args, " "))
+		if log != nil {
+			fmt.Fprintf(log, "--- attempt %d: %s (in %s)\n", attempt, step.CommandLine(), step.Wkdir)
+			err = step.runWithTimeout(ctx, root, timeout, log)
+		} else {
+			err = step.runWithTimeout(ctx, root, timeout, nil)
+		}
+		if err == nil {
+			break
+		}
+
+		output := errorOutput(err)
+
+		remaining := policy.Attempts() - attempt
+		if remaining > 0 && ctx.Err() == nil && !policy.ShouldRetry(output) {
+			fmt.Fprintf(out, "failure is not retryable, giving up\n")
+			remaining = 0
+		}
+
+		var delay time.Duration
+		if remaining > 0 && ctx.Err() == nil {
+			delay = policy.Delay(attempt)
+			fmt.Fprintf(out, "retrying command in %s, number of retries remaining: %d\n", delay.Round(time.Second), remaining)
+			event := step.event(StepRetry, sha)
+			event.Retries = remaining
+			event.Error = err.Error()
+			Emit(event)
+		}
+
+		if remaining <= 0 || !sleep(ctx, delay) {
+			event := step.event(StepFailed, sha)
+			event.Duration = time.Since(start).Seconds()
+			event.Error = err.Error()
+			event.Output = output
+			Emit(event)
+			if log != nil {
+				fmt.Fprintf(out, "full output logged to %s\n", log.Name())
+			}
+			return err
+		}
+	}
+
+	event := step.event(StepSucceeded, sha)
+	event.Duration = time.Since(start).Seconds()
+	Emit(event)
+	return nil
+}
+
+// sleep waits for the delay, returning false if ctx is cancelled first
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (step Step) runWithTimeout(ctx context.Context, root string, timeout time.Duration, log io.Writer) error {
+	if timeout <= 0 {
+		return step.run(ctx, root, log)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := step.run(ctx, root, log)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("step %s timed out after %s: %w", step.Name, timeout, err)
 	}
+	return err
+}
+
+func (step Step) timeout() (time.Duration, error) {
+	if step.Timeout == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(step.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q for step %s: %w", step.Timeout, step.Name, err)
+	}
+	return timeout, nil
+}
+
+func (step Step) CommandLine() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", step.Command, strings.Join(step.Args, " ")))
+}
 
-	return current, err
+// errorOutput is the captured command output carried by err, if any
+func errorOutput(err error) string {
+	var we *WrappedError
+	if errors.As(err, &we) {
+		return we.Output
+	}
+	return ""
+}
+
+func (step Step) event(typ EventType, newSha string) *Event {
+	return &Event{
+		Type:    typ,
+		Repo:    step.Repo,
+		Step:    step.Name,
+		Command: step.CommandLine(),
+		OldSha:  step.Sha,
+		NewSha:  newSha,
+	}
 }
 
 func MkHash(root string, ignore []string) (string, error) {
@@ -117,10 +324,10 @@ func filteredHash(root string, ignore []string) (string, error) {
 		return "", err
 	}
 
+	matcher := newIgnoreMatcher(ignore)
 	keep := []string{}
 	for _, file := range files {
-		trimmed := strings.TrimPrefix(file, root)
-		if ignorePath(trimmed, ignore) {
+		if matcher.ignored(file) {
 			continue
 		}
 
@@ -133,13 +340,3 @@ func filteredHash(root string, ignore []string) (string, error) {
 
 	return dirhash.Hash1(keep, osOpen)
 }
-
-func ignorePath(file string, ignore []string) bool {
-	for _, pref := range ignore {
-		if strings.HasPrefix(file, pref) {
-			return true
-		}
-	}
-
-	return false
-}
@@ -0,0 +1,76 @@
+package executor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		retries  int
+		sha      string
+		expected []executor.EventType
+	}{
+		{
+			name:     `test successful step`,
+			command:  "true",
+			expected: []executor.EventType{executor.StepStarted, executor.StepSucceeded},
+		},
+		{
+			name:     `test failing step with retries`,
+			command:  "false",
+			retries:  1,
+			expected: []executor.EventType{executor.StepStarted, executor.StepRetry, executor.StepFailed},
+		},
+		{
+			name:     `test unchanged step`,
+			command:  "true",
+			sha:      "current",
+			expected: []executor.EventType{executor.StepSkipped},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "executor")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			target := filepath.Join(dir, "target")
+			err = ioutil.WriteFile(target, []byte("target"), 0644)
+			assert.NoError(t, err)
+
+			sha := test.sha
+			if sha == "current" {
+				sha, err = executor.MkHash(target, []string{})
+				assert.NoError(t, err)
+			}
+
+			var buf bytes.Buffer
+			executor.EnableEvents(&buf)
+			defer executor.DisableEvents()
+			step := executor.Step{Name: "test", Wkdir: ".", Target: "target", Command: test.command, Sha: sha, Retries: test.retries, Repo: "repo", Out: ioutil.Discard}
+			_, _ = step.Execute(context.Background(), dir, []string{})
+
+			types := []executor.EventType{}
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				event := executor.Event{}
+				assert.NoError(t, dec.Decode(&event))
+				assert.Equal(t, "repo", event.Repo)
+				assert.Equal(t, "test", event.Step)
+				types = append(types, event.Type)
+			}
+			assert.Equal(t, test.expected, types)
+		})
+	}
+}
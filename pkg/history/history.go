@@ -0,0 +1,207 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+	StatusCancelled = "cancelled"
+
+	// only the tail of a failed step's output is kept, that's where the error usually is
+	maxOutput = 4096
+)
+
+// Entry is a single deploy of a repo, added to the repo's journal at .plural/history
+type Entry struct {
+	Repo      string        `json:"repo"`
+	Status    string        `json:"status"`
+	User      string        `json:"user"`
+	Commit    string        `json:"commit"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  float64       `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+	Steps     []*StepResult `json:"steps"`
+}
+
+type StepResult struct {
+	Name     string  `json:"name"`
+	Command  string  `json:"command"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	ExitCode int     `json:"exit_code"`
+	Output   string  `json:"output,omitempty"`
+}
+
+// Dir is the repo's journal.  Every deploy gets its own file in it, so clones deploying in parallel
+// never conflict when they sync
+func Dir(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, ".plural", "history"))
+}
+
+// Journaled reports whether path, relative to the workspace root as git reports it, is in a repo's
+// journal.  Recording a deploy isn't a change that needs deploying
+func Journaled(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) > 2 && parts[1] == ".plural" && parts[2] == "history"
+}
+
+// NewEntry starts a journal entry for a deploy of repo by the current user at the current commit
+func NewEntry(repo string) *Entry {
+	conf := config.Read()
+	commit, _ := git.HeadCommit()
+	return &Entry{
+		Repo:      repo,
+		User:      conf.Email,
+		Commit:    commit,
+		StartedAt: time.Now(),
+		Steps:     make([]*StepResult, 0),
+	}
+}
+
+// Step records the outcome of a step that started at start, with output being its captured output
+func (e *Entry) Step(name, command string, start time.Time, skipped bool, output string, err error) {
+	result := &StepResult{
+		Name:     name,
+		Command:  command,
+		Status:   status(err),
+		Duration: time.Since(start).Seconds(),
+	}
+
+	if skipped {
+		result.Status = StatusSkipped
+	}
+
+	if err != nil {
+		result.ExitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = exitErr.ExitCode()
+		}
+		result.Output = truncate(output)
+	}
+
+	e.Steps = append(e.Steps, result)
+}
+
+func (e *Entry) Finish(err error, cancelled bool) {
+	e.Duration = time.Since(e.StartedAt).Seconds()
+	e.Status = status(err)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if cancelled {
+		e.Status = StatusCancelled
+	}
+}
+
+// Append adds the entry to the journal at dir as a new file, named for when the deploy started
+func Append(dir string, entry *Entry) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.json", entry.StartedAt.UTC().Format("20060102T150405.000000000"), utils.Sha(contents)[:8])
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(contents, '\n'))
+	return err
+}
+
+// Read returns every entry in the journal at dir, oldest first.  A missing journal has no entries
+func Read(dir string) ([]*Entry, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []*Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		contents, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &Entry{}
+		if err := json.Unmarshal(contents, entry); err != nil {
+			return nil, fmt.Errorf("malformed history entry %s: %w", f.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+	return entries, nil
+}
+
+// Filter narrows entries down to those with a status, started between since and until.  Empty
+// fields match everything
+type Filter struct {
+	Status string
+	Since  time.Time
+	Until  time.Time
+}
+
+func (f *Filter) Apply(entries []*Entry) []*Entry {
+	res := make([]*Entry, 0)
+	for _, entry := range entries {
+		if f.Status != "" && entry.Status != f.Status {
+			continue
+		}
+		if !f.Since.IsZero() && entry.StartedAt.Before(f.Since) {
+			continue
+		}
+		if !f.Until.IsZero() && entry.StartedAt.After(f.Until) {
+			continue
+		}
+		res = append(res, entry)
+	}
+	return res
+}
+
+func status(err error) string {
+	if err != nil {
+		return StatusFailed
+	}
+	return StatusSucceeded
+}
+
+func truncate(output string) string {
+	if len(output) <= maxOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxOutput:]
+}
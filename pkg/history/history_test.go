@@ -0,0 +1,121 @@
+package history_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/history"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	journal := history.Dir(dir, "airflow")
+	entries, err := history.Read(journal)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now()
+	second := &history.Entry{Repo: "airflow", Status: history.StatusFailed, User: "someone@plural.sh", StartedAt: now, Error: "boom"}
+	first := &history.Entry{Repo: "airflow", Status: history.StatusSucceeded, User: "me@plural.sh", StartedAt: now.Add(-time.Hour)}
+	assert.NoError(t, history.Append(journal, second))
+	assert.NoError(t, history.Append(journal, first))
+
+	files, err := ioutil.ReadDir(journal)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	entries, err = history.Read(journal)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "me@plural.sh", entries[0].User)
+	assert.Equal(t, "someone@plural.sh", entries[1].User)
+	assert.Equal(t, "boom", entries[1].Error)
+}
+
+func TestJournaled(t *testing.T) {
+	tests := []struct {
+		path      string
+		journaled bool
+	}{
+		{path: "airflow/.plural/history/20220101T000000.000000000-abcdefgh.json", journaled: true},
+		{path: "airflow/.plural/history/", journaled: true},
+		{path: "airflow/.plural/values.base.yaml"},
+		{path: "airflow/helm/airflow/values.yaml"},
+		{path: "history/.plural"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.journaled, history.Journaled(test.path))
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	now := time.Now()
+	entries := []*history.Entry{
+		{Repo: "airflow", Status: history.StatusSucceeded, StartedAt: now.Add(-72 * time.Hour)},
+		{Repo: "airflow", Status: history.StatusFailed, StartedAt: now.Add(-24 * time.Hour)},
+		{Repo: "airflow", Status: history.StatusSucceeded, StartedAt: now},
+	}
+
+	tests := []struct {
+		name     string
+		filter   *history.Filter
+		expected []*history.Entry
+	}{
+		{
+			name:     `test empty filter matches everything`,
+			filter:   &history.Filter{},
+			expected: entries,
+		},
+		{
+			name:     `test filtering by status`,
+			filter:   &history.Filter{Status: history.StatusFailed},
+			expected: entries[1:2],
+		},
+		{
+			name:     `test filtering by time`,
+			filter:   &history.Filter{Since: now.Add(-48 * time.Hour), Until: now.Add(-time.Hour)},
+			expected: entries[1:2],
+		},
+		{
+			name:     `test filtering by status and time`,
+			filter:   &history.Filter{Status: history.StatusSucceeded, Since: now.Add(-48 * time.Hour)},
+			expected: entries[2:],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.Apply(entries))
+		})
+	}
+}
+
+func TestStepTruncatesOutput(t *testing.T) {
+	entry := &history.Entry{}
+	start := time.Now()
+	long := strings.Repeat("x", 10000) + "error: the real failure"
+
+	entry.Step("terraform-init", "terraform init", start, false, "all good", nil)
+	entry.Step("terraform-apply", "terraform apply", start, false, long, fmt.Errorf("failed"))
+
+	assert.Equal(t, history.StatusSucceeded, entry.Steps[0].Status)
+	assert.Empty(t, entry.Steps[0].Output)
+
+	failed := entry.Steps[1]
+	assert.Equal(t, history.StatusFailed, failed.Status)
+	assert.Equal(t, -1, failed.ExitCode)
+	assert.Equal(t, 4096+len("..."), len(failed.Output))
+	assert.True(t, strings.HasPrefix(failed.Output, "..."))
+	assert.True(t, strings.HasSuffix(failed.Output, "error: the real failure"))
+}
@@ -0,0 +1,334 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+const DefaultTTL = 2 * time.Hour
+
+// Lock records who is currently deploying a workspace, so concurrent runs against the same cluster
+// fail fast instead of racing on terraform state and deploy.hcl shas
+type Lock struct {
+	Owner     string    `yaml:"owner" json:"owner"`
+	Hostname  string    `yaml:"hostname" json:"hostname"`
+	Pid       int       `yaml:"pid" json:"pid"`
+	Command   string    `yaml:"command" json:"command"`
+	StartedAt time.Time `yaml:"startedAt" json:"started_at"`
+	TTL       string    `yaml:"ttl" json:"ttl"`
+}
+
+// LockedError is returned when the workspace is already locked by a live holder
+type LockedError struct {
+	Lock   *Lock
+	Shared bool
+}
+
+func (e *LockedError) Error() string {
+	kind := "locally"
+	if e.Shared {
+		kind = "on origin"
+	}
+	return fmt.Sprintf("workspace is locked %s by %s, run `plural lock break` if you're sure it's abandoned", kind, e.Lock)
+}
+
+var (
+	heldLock sync.Mutex
+	held     int
+	release  func() error
+)
+
+// LocalPath is the lock file preventing concurrent runs from the same clone.  It's kept under the git
+// dir, scoped by root's path within the repo, so git.Sync can never commit it
+func LocalPath(root string) string {
+	gitDir, err := git.GitDir(root)
+	if err != nil {
+		return pathing.SanitizeFilepath(filepath.Join(root, ".plural", "deploy.lock"))
+	}
+
+	prefix, _ := git.Prefix(root)
+	return pathing.SanitizeFilepath(filepath.Join(gitDir, "plural", prefix, "deploy.lock"))
+}
+
+// sharedFile is the file holding the shared lock in the commits of its ref
+const sharedFile = "deploy.lock"
+
+// SharedRef is the ref on origin holding the shared lock of the workspace at root, covering teammates
+// deploying from their own clones.  It's kept off every branch, so taking the lock never pushes anyone's
+// work, and is scoped by root's path within the repo so each environment is locked separately
+func SharedRef(root string) string {
+	prefix, _ := git.Prefix(root)
+	prefix = strings.Trim(filepath.ToSlash(prefix), "/")
+	if prefix == "" {
+		return "refs/plural/lock"
+	}
+	return "refs/plural/lock-" + strings.ReplaceAll(prefix, "/", "-")
+}
+
+// New creates a lock for the current user and process running command
+func New(command string, ttl time.Duration) *Lock {
+	conf := config.Read()
+	hostname, _ := os.Hostname()
+	return &Lock{
+		Owner:     conf.Email,
+		Hostname:  hostname,
+		Pid:       os.Getpid(),
+		Command:   command,
+		StartedAt: time.Now(),
+		TTL:       ttl.String(),
+	}
+}
+
+func (l *Lock) String() string {
+	return fmt.Sprintf("%s on %s (pid %d, running %s since %s)", l.Owner, l.Hostname, l.Pid, l.Command, l.StartedAt.Format(time.RFC3339))
+}
+
+func (l *Lock) Expired() bool {
+	ttl, err := time.ParseDuration(l.TTL)
+	if err != nil {
+		ttl = DefaultTTL
+	}
+	return time.Now().After(l.StartedAt.Add(ttl))
+}
+
+// Stale locks are safe to take over: either past their ttl, or held by a process on this host
+// that no longer exists
+func (l *Lock) Stale() bool {
+	if l.Expired() {
+		return true
+	}
+
+	hostname, _ := os.Hostname()
+	return l.Hostname == hostname && !alive(l.Pid)
+}
+
+// Mine reports whether the lock is held by this process
+func (l *Lock) Mine() bool {
+	hostname, _ := os.Hostname()
+	return l.Hostname == hostname && l.Pid == os.Getpid()
+}
+
+func Read(path string) (*Lock, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(contents)
+}
+
+func parse(contents []byte) (*Lock, error) {
+	lock := &Lock{}
+	if err := yaml.Unmarshal(contents, lock); err != nil {
+		return nil, err
+	}
+	if lock.Pid == 0 {
+		return nil, fmt.Errorf("malformed lock file")
+	}
+	return lock, nil
+}
+
+func (l *Lock) marshal() ([]byte, error) {
+	return yaml.Marshal(l)
+}
+
+// Acquire locks the workspace at root for command, also taking the shared git lock if the workspace
+// is configured for one.  Nested acquisitions within the same process share the outer lock.  The
+// returned func releases whatever was taken
+func Acquire(root, command string) (func() error, error) {
+	heldLock.Lock()
+	defer heldLock.Unlock()
+	if held > 0 {
+		held++
+		return releaseNested, nil
+	}
+
+	conf := settings()
+	ttl := DefaultTTL
+	if conf.TTL != "" {
+		dur, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid lock ttl %q in workspace.yaml: %w", conf.TTL, err)
+		}
+		ttl = dur
+	}
+
+	lock := New(command, ttl)
+	if err := AcquireLocal(LocalPath(root), lock); err != nil {
+		return nil, err
+	}
+
+	var commit string
+	if conf.Shared {
+		var err error
+		if commit, err = AcquireShared(root, lock); err != nil {
+			_ = os.Remove(LocalPath(root))
+			return nil, err
+		}
+	}
+
+	held = 1
+	release = func() error {
+		if conf.Shared {
+			if err := DeleteShared(root, commit); err != nil {
+				utils.Warn("failed to release the shared workspace lock: %s\n", err)
+			}
+		}
+		return os.Remove(LocalPath(root))
+	}
+	return releaseNested, nil
+}
+
+func releaseNested() error {
+	heldLock.Lock()
+	defer heldLock.Unlock()
+	held--
+	if held > 0 {
+		return nil
+	}
+
+	fn := release
+	release = nil
+	return fn()
+}
+
+// AcquireLocal atomically creates the lock file at path, taking over a stale lock if one exists
+func AcquireLocal(path string, lock *Lock) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	contents, err := lock.marshal()
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = f.Write(contents)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			return err
+		}
+
+		if !os.IsExist(err) {
+			return err
+		}
+
+		existing, err := Read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		// an unparseable lock is most likely still being written by its holder
+		if err != nil {
+			return fmt.Errorf("workspace is locked, but %s can't be read: %w", path, err)
+		}
+
+		if !existing.Stale() {
+			return &LockedError{Lock: existing}
+		}
+
+		utils.Warn("taking over stale workspace lock held by %s\n", existing)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("could not acquire the workspace lock at %s", path)
+}
+
+// AcquireShared pushes lock to the shared ref of the workspace at root, unless a live lock of someone
+// else is already there.  It returns the commit holding the lock, which releasing it expects
+func AcquireShared(root string, lock *Lock) (string, error) {
+	ref := SharedRef(root)
+	existing, prev, err := readShared(root)
+	if err != nil {
+		return "", err
+	}
+
+	if existing != nil && !existing.Stale() && !existing.Mine() {
+		return "", &LockedError{Lock: existing, Shared: true}
+	}
+
+	contents, err := lock.marshal()
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := git.PushRefFile(root, ref, prev, sharedFile, fmt.Sprintf("locking workspace for %s", lock.Command), contents)
+	if err != nil {
+		// most likely a teammate took the lock since it was read
+		if existing, _, rerr := readShared(root); rerr == nil && existing != nil && !existing.Mine() {
+			return "", &LockedError{Lock: existing, Shared: true}
+		}
+		return "", err
+	}
+	return commit, nil
+}
+
+// DeleteShared releases the shared lock, as long as it's still the one pushed in commit
+func DeleteShared(root, commit string) error {
+	return git.DeleteRef(root, SharedRef(root), commit)
+}
+
+// ReadShared reads the shared lock from origin, so locks pushed by teammates are seen without pulling.
+// Returns nil if there's no shared lock
+func ReadShared(root string) (*Lock, error) {
+	lock, _, err := readShared(root)
+	return lock, err
+}
+
+func readShared(root string) (*Lock, string, error) {
+	commit, contents, err := git.RefFile(root, SharedRef(root), sharedFile)
+	if err != nil || contents == nil {
+		return nil, commit, err
+	}
+
+	lock, err := parse(contents)
+	return lock, commit, err
+}
+
+// Break forcibly removes the local lock, and the shared one as well if shared is set
+func Break(root string, shared bool) error {
+	if err := os.Remove(LocalPath(root)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !shared {
+		return nil
+	}
+
+	_, commit, err := readShared(root)
+	if err != nil || commit == "" {
+		return err
+	}
+	return DeleteShared(root, commit)
+}
+
+func settings() *manifest.LockConfig {
+	project, err := manifest.FetchProject()
+	if err != nil || project.Lock == nil {
+		return &manifest.LockConfig{}
+	}
+	return project.Lock
+}
+
+// SharedEnabled reports whether the workspace is configured to take a shared git lock
+func SharedEnabled() bool {
+	return settings().Shared
+}
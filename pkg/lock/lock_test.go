@@ -0,0 +1,164 @@
+package lock_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/lock"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestAcquireLocal(t *testing.T) {
+	hostname, _ := os.Hostname()
+	cmd := exec.Command("true")
+	assert.NoError(t, cmd.Run())
+	dead := cmd.ProcessState.Pid()
+
+	tests := []struct {
+		name     string
+		existing *lock.Lock
+		locked   bool
+	}{
+		{
+			name: `test unlocked workspace`,
+		},
+		{
+			name:     `test lock held by a live process`,
+			existing: &lock.Lock{Owner: "someone@plural.sh", Hostname: hostname, Pid: os.Getppid(), StartedAt: time.Now(), TTL: "1h"},
+			locked:   true,
+		},
+		{
+			name:     `test lock held by a live process on another host`,
+			existing: &lock.Lock{Owner: "someone@plural.sh", Hostname: "elsewhere", Pid: dead, StartedAt: time.Now(), TTL: "1h"},
+			locked:   true,
+		},
+		{
+			name:     `test lock held by a dead process is taken over`,
+			existing: &lock.Lock{Owner: "someone@plural.sh", Hostname: hostname, Pid: dead, StartedAt: time.Now(), TTL: "1h"},
+		},
+		{
+			name:     `test expired lock is taken over`,
+			existing: &lock.Lock{Owner: "someone@plural.sh", Hostname: "elsewhere", Pid: 1, StartedAt: time.Now().Add(-2 * time.Hour), TTL: "1h"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "lock")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, ".plural", "deploy.lock")
+			if test.existing != nil {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				contents, err := yaml.Marshal(test.existing)
+				assert.NoError(t, err)
+				assert.NoError(t, ioutil.WriteFile(path, contents, 0644))
+			}
+
+			mine := &lock.Lock{Owner: "me@plural.sh", Hostname: hostname, Pid: os.Getpid(), Command: "deploy", StartedAt: time.Now(), TTL: "1h"}
+			err = lock.AcquireLocal(path, mine)
+			if test.locked {
+				var locked *lock.LockedError
+				assert.True(t, errors.As(err, &locked))
+				assert.Equal(t, test.existing.Owner, locked.Lock.Owner)
+				return
+			}
+
+			assert.NoError(t, err)
+			held, err := lock.Read(path)
+			assert.NoError(t, err)
+			assert.True(t, held.Mine())
+			assert.Equal(t, "me@plural.sh", held.Owner)
+		})
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	assert.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, ".plural", "deploy.lock"), lock.LocalPath(dir))
+
+	assert.NoError(t, exec.Command("git", "init", dir).Run())
+	env := filepath.Join(dir, "environments", "dev")
+	assert.NoError(t, os.MkdirAll(env, 0755))
+
+	assert.Equal(t, filepath.Join(dir, ".git", "plural", "deploy.lock"), lock.LocalPath(dir))
+	assert.Equal(t, filepath.Join(dir, ".git", "plural", "environments", "dev", "deploy.lock"), lock.LocalPath(env))
+}
+
+func TestSharedLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "plural")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "me@plural.sh")
+	}
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+
+	origin, mine, theirs := filepath.Join(dir, "origin"), filepath.Join(dir, "mine"), filepath.Join(dir, "theirs")
+	run(dir, "init", "-q", "--bare", origin)
+	run(dir, "clone", "-q", origin, mine)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(mine, "workspace.yaml"), []byte("provider: kind\n"), 0644))
+	run(mine, "add", ".")
+	run(mine, "commit", "-q", "-m", "init")
+	run(mine, "push", "-q", "origin", "HEAD")
+	run(dir, "clone", "-q", origin, theirs)
+
+	// an unpushed local commit must stay local when the lock is taken
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(mine, "context.yaml"), []byte("configuration: {}\n"), 0644))
+	run(mine, "add", ".")
+	run(mine, "commit", "-q", "-m", "unpushed")
+	head := run(mine, "rev-parse", "HEAD")
+	pushed := run(origin, "rev-parse", "HEAD")
+
+	held := &lock.Lock{Owner: "me@plural.sh", Hostname: "elsewhere", Pid: 1, Command: "deploy", StartedAt: time.Now(), TTL: "1h"}
+	commit, err := lock.AcquireShared(mine, held)
+	assert.NoError(t, err)
+	assert.Equal(t, head, run(mine, "rev-parse", "HEAD"))
+	assert.Equal(t, pushed, run(origin, "rev-parse", "HEAD"))
+
+	seen, err := lock.ReadShared(theirs)
+	assert.NoError(t, err)
+	assert.Equal(t, "me@plural.sh", seen.Owner)
+
+	hostname, _ := os.Hostname()
+	other := &lock.Lock{Owner: "someone@plural.sh", Hostname: hostname, Pid: os.Getpid(), Command: "deploy", StartedAt: time.Now(), TTL: "1h"}
+	_, err = lock.AcquireShared(theirs, other)
+	var locked *lock.LockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.True(t, locked.Shared)
+
+	assert.NoError(t, lock.DeleteShared(mine, commit))
+	seen, err = lock.ReadShared(theirs)
+	assert.NoError(t, err)
+	assert.Nil(t, seen)
+
+	_, err = lock.AcquireShared(theirs, other)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Break(theirs, true))
+	seen, err = lock.ReadShared(mine)
+	assert.NoError(t, err)
+	assert.Nil(t, seen)
+}
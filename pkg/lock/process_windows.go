@@ -0,0 +1,13 @@
+package lock
+
+import "os"
+
+// on windows FindProcess opens a handle to the process, which fails if it's gone
+func alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
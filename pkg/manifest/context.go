@@ -2,7 +2,6 @@ package manifest
 
 import (
 	"fmt"
-	"io/ioutil"
 	"path/filepath"
 
 	"github.com/pluralsh/plural/pkg/api"
@@ -54,12 +53,17 @@ func BuildContext(path string, insts []*api.Installation) error {
 	return ctx.Write(path)
 }
 
+// ReadContext reads a context.yaml, with the selected environment's overlay merged in
 func ReadContext(path string) (c *Context, err error) {
-	contents, err := ioutil.ReadFile(path)
+	contents, err := readResolved(path)
 	if err != nil {
 		return
 	}
 
+	return parseContext(contents)
+}
+
+func parseContext(contents []byte) (c *Context, err error) {
 	ctx := &VersionedContext{}
 	err = yaml.Unmarshal(contents, ctx)
 	c = ctx.Spec
@@ -122,18 +126,22 @@ func (c *Context) HasDomain(domain string) bool {
 }
 
 func (c *Context) Write(path string) error {
+	io, err := c.marshal()
+	if err != nil {
+		return err
+	}
+
+	return writeResolved(path, io)
+}
+
+func (c *Context) marshal() ([]byte, error) {
 	versioned := &VersionedContext{
 		ApiVersion: "plural.sh/v1alpha1",
 		Kind:       "Context",
 		Spec:       c,
 	}
 
-	io, err := yaml.Marshal(versioned)
-	if err != nil {
-		return err
-	}
-
-	return ioutil.WriteFile(path, io, 0644)
+	return yaml.Marshal(versioned)
 }
 
 func (c *Context) ContainsString(str, msg, ignoreRepo, ignoreKey string) error {
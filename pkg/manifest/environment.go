@@ -0,0 +1,305 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvironmentsDir holds a directory per environment of an installation repo, each with overlays of the
+// base workspace.yaml and context.yaml and the workspace generated for that environment
+const EnvironmentsDir = "environments"
+
+type environment struct {
+	name string
+	// root holds the base workspace.yaml and context.yaml
+	root string
+	// dir holds the environment's overlays and generated workspace
+	dir string
+}
+
+var selected *environment
+
+func EnvironmentDir(root, name string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, EnvironmentsDir, name))
+}
+
+// Environments lists the environments of the installation repo at root
+func Environments(root string) ([]string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(root, EnvironmentsDir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() && utils.Exists(filepath.Join(root, EnvironmentsDir, entry.Name(), "workspace.yaml")) {
+			envs = append(envs, entry.Name())
+		}
+	}
+	sort.Strings(envs)
+	return envs, nil
+}
+
+// SelectEnvironment makes every later read of workspace.yaml and context.yaml return the base files with
+// the environment's overlays merged in, and every write store what differs from the base in the
+// overlays.  It returns the directory the environment's workspace is generated in
+func SelectEnvironment(root, name string) (string, error) {
+	env, err := findEnvironment(root, name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateBuckets(root); err != nil {
+		return "", err
+	}
+
+	selected = env
+	return env.dir, nil
+}
+
+// Environment returns the selected environment, or an empty string if the base workspace is used
+func Environment() string {
+	if selected == nil {
+		return ""
+	}
+	return selected.name
+}
+
+// validateBuckets makes sure no two environments, or an environment and the base workspace, share a
+// terraform state bucket
+func validateBuckets(root string) error {
+	envs, err := Environments(root)
+	if err != nil {
+		return err
+	}
+
+	base, err := ioutil.ReadFile(filepath.Join(root, "workspace.yaml"))
+	if err != nil {
+		return fmt.Errorf("could not find workspace.yaml file, you might need to run `plural init`")
+	}
+
+	owners := map[string]string{}
+	if proj, err := parseProject(base); err == nil && proj.Bucket != "" {
+		owners[proj.Bucket] = "the base workspace"
+	}
+
+	for _, env := range envs {
+		overlay, err := ioutil.ReadFile(filepath.Join(EnvironmentDir(root, env), "workspace.yaml"))
+		if err != nil {
+			return err
+		}
+		merged, err := mergeYaml(base, overlay)
+		if err != nil {
+			return fmt.Errorf("failed to merge the workspace.yaml overlay of environment %s: %w", env, err)
+		}
+		proj, err := parseProject(merged)
+		if err != nil || proj.Bucket == "" {
+			continue
+		}
+
+		if owner, ok := owners[proj.Bucket]; ok {
+			return fmt.Errorf("environment %s shares the state bucket %s with %s, set its own bucket in its workspace.yaml overlay", env, proj.Bucket, owner)
+		}
+		owners[proj.Bucket] = fmt.Sprintf("environment %s", env)
+	}
+	return nil
+}
+
+// ReadEnvironmentContext reads the context of an environment, with its overlay merged in, whether or
+// not it's the selected one
+func ReadEnvironmentContext(root, name string) (*Context, error) {
+	env, err := findEnvironment(root, name)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := env.read(filepath.Join(root, "context.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	return parseContext(contents)
+}
+
+// WriteEnvironmentContext writes what differs between ctx and the base context.yaml to an
+// environment's overlay of it
+func WriteEnvironmentContext(root, name string, ctx *Context) error {
+	env, err := findEnvironment(root, name)
+	if err != nil {
+		return err
+	}
+
+	io, err := ctx.marshal()
+	if err != nil {
+		return err
+	}
+	return env.write(filepath.Join(root, "context.yaml"), io)
+}
+
+func findEnvironment(root, name string) (*environment, error) {
+	dir := EnvironmentDir(root, name)
+	if !utils.Exists(filepath.Join(dir, "workspace.yaml")) {
+		return nil, fmt.Errorf("environment %s not found, create it with a workspace.yaml overlay at %s", name, dir)
+	}
+	return &environment{name: name, root: root, dir: dir}, nil
+}
+
+// paths maps a workspace.yaml or context.yaml, either the base file or its overlay, to the base file
+// and the environment's overlay of it
+func (env *environment) paths(path string) (base, overlay string, ok bool) {
+	dir, file := filepath.Dir(path), filepath.Base(path)
+	if dir != env.root && dir != env.dir {
+		return "", "", false
+	}
+	return filepath.Join(env.root, file), filepath.Join(env.dir, file), true
+}
+
+// readResolved reads a file, merging in the selected environment's overlay of it
+func readResolved(path string) ([]byte, error) {
+	if selected == nil {
+		return ioutil.ReadFile(path)
+	}
+	return selected.read(path)
+}
+
+// writeResolved writes a file, or only what differs from the base file to the selected environment's
+// overlay of it, leaving the base untouched
+func writeResolved(path string, contents []byte) error {
+	if selected == nil {
+		return ioutil.WriteFile(path, contents, 0644)
+	}
+	return selected.write(path, contents)
+}
+
+func (env *environment) read(path string) ([]byte, error) {
+	base, overlay, ok := env.paths(path)
+	if !ok {
+		return ioutil.ReadFile(path)
+	}
+
+	contents, err := ioutil.ReadFile(base)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.Exists(overlay) {
+		return contents, nil
+	}
+
+	overlayContents, err := ioutil.ReadFile(overlay)
+	if err != nil {
+		return nil, err
+	}
+	return mergeYaml(contents, overlayContents)
+}
+
+func (env *environment) write(path string, contents []byte) error {
+	base, overlay, ok := env.paths(path)
+	if !ok || !utils.Exists(base) {
+		return ioutil.WriteFile(path, contents, 0644)
+	}
+
+	baseContents, err := ioutil.ReadFile(base)
+	if err != nil {
+		return err
+	}
+
+	var baseVals, vals map[interface{}]interface{}
+	if err := yaml.Unmarshal(baseContents, &baseVals); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(contents, &vals); err != nil {
+		return err
+	}
+
+	diff := diffYaml(baseVals, vals)
+	for _, key := range []string{"apiVersion", "kind"} {
+		if val, ok := vals[key]; ok {
+			diff[key] = val
+		}
+	}
+
+	io, err := yaml.Marshal(diff)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(overlay, io, 0644)
+}
+
+// mergeYaml deep merges overlay into base, with any value in overlay replacing base's apart from maps,
+// which are merged key by key
+func mergeYaml(base, overlay []byte) ([]byte, error) {
+	var baseVals, overlayVals map[interface{}]interface{}
+	if err := yaml.Unmarshal(base, &baseVals); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(overlay, &overlayVals); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(mergeMaps(baseVals, overlayVals))
+}
+
+func mergeMaps(base, overlay map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range overlay {
+		baseMap, baseOk := merged[k].(map[interface{}]interface{})
+		overlayMap, overlayOk := v.(map[interface{}]interface{})
+		if baseOk && overlayOk {
+			merged[k] = mergeMaps(baseMap, overlayMap)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// diffYaml returns the parts of vals that differ from base, such that merging them into base gives
+// vals back, apart from keys removed from base which an overlay can't express
+func diffYaml(base, vals map[interface{}]interface{}) map[interface{}]interface{} {
+	diff := make(map[interface{}]interface{})
+	for k, v := range vals {
+		baseVal, ok := base[k]
+		if (ok && reflect.DeepEqual(baseVal, v)) || (!ok && isEmpty(v)) {
+			continue
+		}
+
+		baseMap, baseOk := baseVal.(map[interface{}]interface{})
+		valMap, valOk := v.(map[interface{}]interface{})
+		if baseOk && valOk {
+			if sub := diffYaml(baseMap, valMap); len(sub) > 0 {
+				diff[k] = sub
+			}
+			continue
+		}
+		diff[k] = v
+	}
+	return diff
+}
+
+// isEmpty reports whether a value is one marshalling a struct fills in for fields a file left out
+func isEmpty(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(val); v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return false
+	}
+}
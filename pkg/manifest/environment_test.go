@@ -0,0 +1,101 @@
+package manifest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+const baseWorkspace = `apiVersion: plural.sh/v1alpha1
+kind: ProjectManifest
+spec:
+  cluster: prod
+  bucket: acme-prod-tf-state
+  project: acme
+  provider: aws
+  region: us-east-1
+`
+
+const baseContext = `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  bundles:
+  - repository: airflow
+    name: airflow-aws
+  configuration:
+    airflow:
+      hostname: airflow.acme.com
+      replicas: 3
+`
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, contents := range files {
+		path = filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+}
+
+func TestEnvironments(t *testing.T) {
+	root, err := ioutil.TempDir("", "environments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"workspace.yaml":                      baseWorkspace,
+		"context.yaml":                        baseContext,
+		"environments/staging/workspace.yaml": "spec:\n  cluster: staging\n  bucket: acme-staging-tf-state\n",
+		"environments/staging/context.yaml":   "spec:\n  configuration:\n    airflow:\n      hostname: airflow.staging.acme.com\n",
+		"environments/dev/workspace.yaml":     "spec:\n  cluster: dev\n",
+	})
+
+	envs, err := manifest.Environments(root)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dev", "staging"}, envs)
+
+	_, err = manifest.SelectEnvironment(root, "staging")
+	assert.EqualError(t, err, "environment dev shares the state bucket acme-prod-tf-state with the base workspace, set its own bucket in its workspace.yaml overlay")
+
+	writeFiles(t, root, map[string]string{"environments/dev/workspace.yaml": "spec:\n  cluster: dev\n  bucket: acme-dev-tf-state\n"})
+	_, err = manifest.SelectEnvironment(root, "qa")
+	assert.Error(t, err)
+
+	dir, err := manifest.SelectEnvironment(root, "staging")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "environments", "staging"), dir)
+	assert.Equal(t, "staging", manifest.Environment())
+
+	project, err := manifest.ReadProject(filepath.Join(dir, "workspace.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "staging", project.Cluster)
+	assert.Equal(t, "acme-staging-tf-state", project.Bucket)
+	assert.Equal(t, "us-east-1", project.Region)
+
+	ctx, err := manifest.ReadContext(filepath.Join(root, "context.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "airflow.staging.acme.com", ctx.Configuration["airflow"]["hostname"])
+	assert.Equal(t, 3, ctx.Configuration["airflow"]["replicas"])
+
+	// writes only land in the overlay, leaving the base untouched
+	ctx.Configuration["airflow"]["replicas"] = 1
+	assert.NoError(t, ctx.Write(filepath.Join(root, "context.yaml")))
+
+	base, err := ioutil.ReadFile(filepath.Join(root, "context.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, baseContext, string(base))
+
+	overlay, err := ioutil.ReadFile(filepath.Join(dir, "context.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, `apiVersion: plural.sh/v1alpha1
+kind: Context
+spec:
+  configuration:
+    airflow:
+      hostname: airflow.staging.acme.com
+      replicas: 1
+`, string(overlay))
+}
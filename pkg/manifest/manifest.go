@@ -49,7 +49,7 @@ func (pMan *ProjectManifest) Write(path string) error {
 		return err
 	}
 
-	return ioutil.WriteFile(path, io, 0644)
+	return writeResolved(path, io)
 }
 
 func FetchProject() (*ProjectManifest, error) {
@@ -57,13 +57,18 @@ func FetchProject() (*ProjectManifest, error) {
 	return ReadProject(path)
 }
 
+// ReadProject reads a workspace.yaml, with the selected environment's overlay merged in
 func ReadProject(path string) (man *ProjectManifest, err error) {
-	contents, err := ioutil.ReadFile(path)
+	contents, err := readResolved(path)
 	if err != nil {
 		err = fmt.Errorf("could not find workspace.yaml file, you might need to run `plural init`")
 		return
 	}
 
+	return parseProject(contents)
+}
+
+func parseProject(contents []byte) (man *ProjectManifest, err error) {
 	versioned := &VersionedProjectManifest{}
 	err = yaml.Unmarshal(contents, versioned)
 	if err != nil || versioned.Spec == nil {
@@ -0,0 +1,199 @@
+package manifest
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// Promotion is a configuration value of a repo that differs between two environments
+type Promotion struct {
+	Repo string
+	Key  string
+	// From is the target's value, or nil if it's unset there
+	From interface{}
+	// To is the source's value
+	To interface{}
+	// EnvironmentSpecific values, like domains and buckets, stay as they are in the target
+	EnvironmentSpecific bool
+}
+
+// VersionPromotion is a chart whose pinned version differs between two environments
+type VersionPromotion struct {
+	Repo  string
+	Chart string
+	// From is the target's version, or empty if the target hasn't built the chart
+	From string
+	// To is the source's version, and VersionId its id
+	To        string
+	VersionId string
+}
+
+// ContextPromotions lists the configuration that differs between source and target for the given repos,
+// or every repo configured in source if none are given.  Keys only the target sets are left alone.  The
+// keys in specific, by repo, are environment specific, as are values the source or target registered as
+// a domain or bucket, which covers keys whose recipes couldn't be fetched
+func ContextPromotions(source, target *Context, repos []string, specific map[string]map[string]bool) []*Promotion {
+	if len(repos) == 0 {
+		for repo := range source.Configuration {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Strings(repos)
+
+	promotions := make([]*Promotion, 0)
+	for _, repo := range repos {
+		conf := source.Configuration[repo]
+		keys := make([]string, 0, len(conf))
+		for key := range conf {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		targetConf := target.Configuration[repo]
+		for _, key := range keys {
+			from, ok := targetConf[key]
+			to := conf[key]
+			if ok && reflect.DeepEqual(from, to) {
+				continue
+			}
+
+			promotions = append(promotions, &Promotion{
+				Repo:                repo,
+				Key:                 key,
+				From:                from,
+				To:                  to,
+				EnvironmentSpecific: specific[repo][key] || source.environmentSpecific(to) || target.environmentSpecific(from),
+			})
+		}
+	}
+	return promotions
+}
+
+func (c *Context) environmentSpecific(val interface{}) bool {
+	str, ok := val.(string)
+	return ok && str != "" && (c.HasDomain(str) || c.HasBucket(str))
+}
+
+// Promote applies every promotion that isn't environment specific
+func (c *Context) Promote(promotions []*Promotion) {
+	if c.Configuration == nil {
+		c.Configuration = make(map[string]map[string]interface{})
+	}
+
+	for _, promotion := range promotions {
+		if promotion.EnvironmentSpecific {
+			continue
+		}
+
+		conf, ok := c.Configuration[promotion.Repo]
+		if !ok {
+			conf = map[string]interface{}{}
+			c.Configuration[promotion.Repo] = conf
+		}
+		conf[promotion.Key] = promotion.To
+	}
+}
+
+// VersionPromotions compares the chart versions pinned in the manifests of two workspaces, for the
+// given repos or every repo built in source if none are given
+func VersionPromotions(source, target string, repos []string) ([]*VersionPromotion, error) {
+	if len(repos) == 0 {
+		matches, err := filepath.Glob(filepath.Join(source, "*", "manifest.yaml"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			repos = append(repos, filepath.Base(filepath.Dir(match)))
+		}
+	}
+	sort.Strings(repos)
+
+	promotions := make([]*VersionPromotion, 0)
+	for _, repo := range repos {
+		sourceVersions, err := chartVersions(source, repo)
+		if err != nil {
+			return nil, err
+		}
+		targetVersions, err := chartVersions(target, repo)
+		if err != nil {
+			return nil, err
+		}
+
+		charts := make([]string, 0, len(sourceVersions))
+		for chart := range sourceVersions {
+			charts = append(charts, chart)
+		}
+		sort.Strings(charts)
+
+		for _, chart := range charts {
+			to, from := sourceVersions[chart], targetVersions[chart]
+			if from == nil {
+				from = &ChartManifest{}
+			}
+			if to.Version != from.Version {
+				promotions = append(promotions, &VersionPromotion{Repo: repo, Chart: chart, From: from.Version, To: to.Version, VersionId: to.VersionId})
+			}
+		}
+	}
+	return promotions, nil
+}
+
+func chartVersions(root, repo string) (map[string]*ChartManifest, error) {
+	versions := map[string]*ChartManifest{}
+	path := pathing.SanitizeFilepath(filepath.Join(root, repo, "manifest.yaml"))
+	if !utils.Exists(path) {
+		return versions, nil
+	}
+
+	man, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, chart := range man.Charts {
+		versions[chart.Name] = chart
+	}
+	return versions, nil
+}
+
+// PromoteVersions pins the promoted chart versions in the manifests of the workspace at target,
+// returning the manifests it rewrote.  Charts the target hasn't built are skipped, building the target
+// pins them
+func PromoteVersions(target string, versions []*VersionPromotion) ([]string, error) {
+	byRepo := map[string]map[string]*VersionPromotion{}
+	repos := make([]string, 0)
+	for _, version := range versions {
+		if version.From == "" {
+			continue
+		}
+		if _, ok := byRepo[version.Repo]; !ok {
+			byRepo[version.Repo] = map[string]*VersionPromotion{}
+			repos = append(repos, version.Repo)
+		}
+		byRepo[version.Repo][version.Chart] = version
+	}
+
+	paths := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		path := pathing.SanitizeFilepath(filepath.Join(target, repo, "manifest.yaml"))
+		man, err := Read(path)
+		if err != nil {
+			return paths, err
+		}
+
+		for _, chart := range man.Charts {
+			if version, ok := byRepo[repo][chart.Name]; ok {
+				chart.Version = version.To
+				chart.VersionId = version.VersionId
+			}
+		}
+		if err := man.Write(path); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
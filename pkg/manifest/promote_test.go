@@ -0,0 +1,126 @@
+package manifest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextPromotions(t *testing.T) {
+	source := &manifest.Context{
+		Domains: []string{"airflow.staging.acme.com"},
+		Configuration: map[string]map[string]interface{}{
+			"airflow": {"hostname": "airflow.staging.acme.com", "bucket": "airflow-staging", "replicas": 3, "workers": 2},
+			"grafana": {"admin": "grafana"},
+		},
+	}
+	target := &manifest.Context{
+		Domains: []string{"airflow.acme.com"},
+		Configuration: map[string]map[string]interface{}{
+			"airflow": {"hostname": "airflow.acme.com", "bucket": "airflow-prod", "replicas": 1, "workers": 2, "extra": true},
+		},
+	}
+	// the bucket is only known to be environment specific from its recipe's configuration type
+	specific := map[string]map[string]bool{"airflow": {"bucket": true}}
+
+	tests := []struct {
+		name     string
+		repos    []string
+		expected []*manifest.Promotion
+	}{
+		{
+			name: `test every differing repo is promoted`,
+			expected: []*manifest.Promotion{
+				{Repo: "airflow", Key: "bucket", From: "airflow-prod", To: "airflow-staging", EnvironmentSpecific: true},
+				{Repo: "airflow", Key: "hostname", From: "airflow.acme.com", To: "airflow.staging.acme.com", EnvironmentSpecific: true},
+				{Repo: "airflow", Key: "replicas", From: 1, To: 3},
+				{Repo: "grafana", Key: "admin", To: "grafana"},
+			},
+		},
+		{
+			name:  `test promotions can be limited to repos`,
+			repos: []string{"grafana"},
+			expected: []*manifest.Promotion{
+				{Repo: "grafana", Key: "admin", To: "grafana"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, manifest.ContextPromotions(source, target, test.repos, specific))
+		})
+	}
+}
+
+func TestPromote(t *testing.T) {
+	ctx := &manifest.Context{
+		Configuration: map[string]map[string]interface{}{
+			"airflow": {"hostname": "airflow.acme.com", "replicas": 1, "extra": true},
+		},
+	}
+
+	ctx.Promote([]*manifest.Promotion{
+		{Repo: "airflow", Key: "hostname", To: "airflow.staging.acme.com", EnvironmentSpecific: true},
+		{Repo: "airflow", Key: "replicas", From: 1, To: 3},
+		{Repo: "grafana", Key: "admin", To: "grafana"},
+	})
+
+	assert.Equal(t, map[string]map[string]interface{}{
+		"airflow": {"hostname": "airflow.acme.com", "replicas": 3, "extra": true},
+		"grafana": {"admin": "grafana"},
+	}, ctx.Configuration)
+}
+
+func TestVersionPromotions(t *testing.T) {
+	source, err := ioutil.TempDir("", "source")
+	assert.NoError(t, err)
+	defer os.RemoveAll(source)
+	target, err := ioutil.TempDir("", "target")
+	assert.NoError(t, err)
+	defer os.RemoveAll(target)
+
+	writeFiles(t, source, map[string]string{
+		"airflow/manifest.yaml": "apiVersion: plural.sh/v1alpha1\nkind: Manifest\nspec:\n  charts:\n  - name: airflow\n    version: 0.2.0\n  - name: postgres\n    version: 0.1.0\n",
+		"grafana/manifest.yaml": "apiVersion: plural.sh/v1alpha1\nkind: Manifest\nspec:\n  charts:\n  - name: grafana\n    version: 1.0.0\n",
+	})
+	writeFiles(t, target, map[string]string{
+		"airflow/manifest.yaml": "apiVersion: plural.sh/v1alpha1\nkind: Manifest\nspec:\n  charts:\n  - name: airflow\n    version: 0.1.0\n  - name: postgres\n    version: 0.1.0\n",
+	})
+
+	promotions, err := manifest.VersionPromotions(source, target, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*manifest.VersionPromotion{
+		{Repo: "airflow", Chart: "airflow", From: "0.1.0", To: "0.2.0"},
+		{Repo: "grafana", Chart: "grafana", To: "1.0.0"},
+	}, promotions)
+}
+
+func TestPromoteVersions(t *testing.T) {
+	target, err := ioutil.TempDir("", "target")
+	assert.NoError(t, err)
+	defer os.RemoveAll(target)
+
+	writeFiles(t, target, map[string]string{
+		"airflow/manifest.yaml": "apiVersion: plural.sh/v1alpha1\nkind: Manifest\nspec:\n  name: airflow\n  charts:\n  - name: airflow\n    versionid: v1\n    version: 0.1.0\n  - name: postgres\n    versionid: p1\n    version: 0.1.0\n",
+	})
+
+	paths, err := manifest.PromoteVersions(target, []*manifest.VersionPromotion{
+		{Repo: "airflow", Chart: "airflow", From: "0.1.0", To: "0.2.0", VersionId: "v2"},
+		{Repo: "grafana", Chart: "grafana", To: "1.0.0", VersionId: "g1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(target, "airflow", "manifest.yaml")}, paths)
+	assert.NoFileExists(t, filepath.Join(target, "grafana", "manifest.yaml"))
+
+	man, err := manifest.Read(filepath.Join(target, "airflow", "manifest.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, []*manifest.ChartManifest{
+		{Name: "airflow", VersionId: "v2", Version: "0.2.0"},
+		{Name: "postgres", VersionId: "p1", Version: "0.1.0"},
+	}, man.Charts)
+}
@@ -48,6 +48,13 @@ type NetworkConfig struct {
 	PluralDns bool
 }
 
+// LockConfig controls the workspace lock taken by deploys.  A shared lock is pushed to a dedicated
+// ref on origin, off every branch, so teammates working from other clones see it too
+type LockConfig struct {
+	Shared bool
+	TTL    string `yaml:"ttl,omitempty"`
+}
+
 type ProjectManifest struct {
 	Cluster      string
 	Bucket       string
@@ -56,8 +63,32 @@ type ProjectManifest struct {
 	Region       string
 	Owner        *Owner
 	Network      *NetworkConfig
-	BucketPrefix string `yaml:"bucketPrefix"`
-	Context      map[string]interface{}
+	BucketPrefix string      `yaml:"bucketPrefix"`
+	Lock         *LockConfig `yaml:"lock,omitempty"`
+	// Notifications are webhooks told about deploys of this workspace, in addition to any in the cli config
+	Notifications []*Webhook `yaml:"notifications,omitempty"`
+	Context       map[string]interface{}
+}
+
+// Webhook is a notification endpoint.  Format is one of json (the default), slack or teams, and
+// Events optionally restricts delivery to some of started, succeeded and failed
+type Webhook struct {
+	Url    string   `yaml:"url"`
+	Format string   `yaml:"format,omitempty"`
+	Events []string `yaml:"events,omitempty"`
+}
+
+func (w *Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
 }
 
 type VersionedManifest struct {
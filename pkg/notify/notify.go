@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+)
+
+type Event string
+
+const (
+	Started   Event = "started"
+	Succeeded Event = "succeeded"
+	Failed    Event = "failed"
+
+	FormatJson  = "json"
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+
+	deliveryTimeout = 10 * time.Second
+)
+
+// Message describes a deploy, destroy or bounce at one point in its lifecycle
+type Message struct {
+	Event     Event     `json:"event"`
+	Command   string    `json:"command"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Repos     []string  `json:"repos"`
+	User      string    `json:"user,omitempty"`
+	Commit    string    `json:"commit,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  float64   `json:"duration,omitempty"`
+	Repo      string    `json:"failed_repo,omitempty"`
+	Step      string    `json:"failed_step,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Output    string    `json:"output,omitempty"`
+}
+
+// Notifier delivers messages for a single command run to every configured webhook.  Delivery is
+// best effort: failures are printed as warnings and never fail the command
+type Notifier struct {
+	hooks   []*manifest.Webhook
+	message *Message
+	current string
+	secrets []string
+	client  *http.Client
+}
+
+// Start notifies the webhooks configured in the cli config and workspace.yaml that command has
+// started against repos
+func Start(command string, repos []string) *Notifier {
+	conf := config.Read()
+	hooks := make([]*manifest.Webhook, 0, len(conf.Notifications))
+	for _, hook := range conf.Notifications {
+		hooks = append(hooks, &manifest.Webhook{Url: hook.Url, Format: hook.Format, Events: hook.Events})
+	}
+	cluster := ""
+	if project, err := manifest.FetchProject(); err == nil {
+		hooks = append(hooks, project.Notifications...)
+		cluster = project.Cluster
+	}
+
+	commit, _ := git.HeadCommit()
+	n := New(hooks, &Message{
+		Command: command,
+		Cluster: cluster,
+		Repos:   repos,
+		User:    conf.Email,
+		Commit:  commit,
+	})
+	n.secrets = append(n.secrets, conf.Token)
+	n.Started()
+	return n
+}
+
+func New(hooks []*manifest.Webhook, message *Message) *Notifier {
+	if message.StartedAt.IsZero() {
+		message.StartedAt = time.Now()
+	}
+
+	return &Notifier{
+		hooks:   hooks,
+		message: message,
+		client:  &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+// Current records the repo being worked on, which is blamed for a failure unless the error says otherwise
+func (n *Notifier) Current(repo string) {
+	n.current = repo
+}
+
+func (n *Notifier) Started() {
+	msg := *n.message
+	msg.Event = Started
+	n.send(&msg)
+}
+
+// Finish sends the outcome of the command, succeeded if err is nil and failed otherwise
+func (n *Notifier) Finish(err error) {
+	msg := *n.message
+	msg.Event = Succeeded
+	msg.Duration = time.Since(msg.StartedAt).Round(time.Second).Seconds()
+	if err != nil {
+		msg.Event = Failed
+		msg.Repo = n.current
+		msg.Error = Redact(err.Error(), n.secrets...)
+
+		var se *executor.StepError
+		if errors.As(err, &se) {
+			msg.Repo = se.Repo
+			msg.Step = se.Step
+		}
+
+		var we *executor.WrappedError
+		if errors.As(err, &we) {
+			msg.Output = Truncate(Redact(we.Output, n.secrets...))
+		}
+	}
+	n.send(&msg)
+}
+
+func (n *Notifier) send(msg *Message) {
+	for _, hook := range n.hooks {
+		if !hook.Wants(string(msg.Event)) {
+			continue
+		}
+
+		if err := n.deliver(hook, msg); err != nil {
+			utils.Warn("failed to deliver %s notification: %s\n", msg.Event, err)
+		}
+	}
+}
+
+func (n *Notifier) deliver(hook *manifest.Webhook, msg *Message) error {
+	payload, err := Payload(hook.Format, msg)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(hook.Url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
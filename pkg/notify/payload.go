@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+var colors = map[Event]string{
+	Started:   "#3b82f6",
+	Succeeded: "#22c55e",
+	Failed:    "#ef4444",
+}
+
+// Payload renders msg in the given webhook format
+func Payload(format string, msg *Message) (interface{}, error) {
+	switch format {
+	case "", FormatJson:
+		return msg, nil
+	case FormatSlack:
+		return slackPayload(msg), nil
+	case FormatTeams:
+		return teamsPayload(msg), nil
+	default:
+		return nil, fmt.Errorf("unsupported notification format %s, must be one of json, slack or teams", format)
+	}
+}
+
+func (msg *Message) Summary() string {
+	target := strings.Join(msg.Repos, ", ")
+	if msg.Cluster != "" {
+		target = fmt.Sprintf("%s on %s", target, msg.Cluster)
+	}
+
+	switch msg.Event {
+	case Failed:
+		where := msg.Repo
+		if msg.Step != "" {
+			where = fmt.Sprintf("%s (step %s)", msg.Repo, msg.Step)
+		}
+		if where == "" {
+			return fmt.Sprintf("plural %s of %s failed", msg.Command, target)
+		}
+		return fmt.Sprintf("plural %s of %s failed in %s", msg.Command, target, where)
+	case Succeeded:
+		return fmt.Sprintf("plural %s of %s succeeded", msg.Command, target)
+	default:
+		return fmt.Sprintf("plural %s of %s started", msg.Command, target)
+	}
+}
+
+type fact struct {
+	name  string
+	value string
+}
+
+func (msg *Message) facts() []fact {
+	facts := []fact{{"Repos", strings.Join(msg.Repos, ", ")}}
+	if msg.User != "" {
+		facts = append(facts, fact{"User", msg.User})
+	}
+	if msg.Commit != "" {
+		facts = append(facts, fact{"Commit", msg.Commit})
+	}
+	facts = append(facts, fact{"Started", msg.StartedAt.Format(time.RFC3339)})
+	if msg.Event != Started {
+		facts = append(facts, fact{"Duration", (time.Duration(msg.Duration) * time.Second).String()})
+	}
+	if msg.Error != "" {
+		facts = append(facts, fact{"Error", msg.Error})
+	}
+	return facts
+}
+
+func slackPayload(msg *Message) map[string]interface{} {
+	fields := []map[string]interface{}{}
+	for _, f := range msg.facts() {
+		fields = append(fields, map[string]interface{}{"title": f.name, "value": f.value, "short": f.name != "Error"})
+	}
+
+	attachment := map[string]interface{}{
+		"color":  colors[msg.Event],
+		"fields": fields,
+	}
+	if msg.Output != "" {
+		attachment["text"] = fmt.Sprintf("```%s```", msg.Output)
+	}
+
+	return map[string]interface{}{
+		"text":        msg.Summary(),
+		"attachments": []interface{}{attachment},
+	}
+}
+
+func teamsPayload(msg *Message) map[string]interface{} {
+	// teams renders cards as html, so anything from command output has to be escaped
+	facts := []map[string]string{}
+	for _, f := range msg.facts() {
+		facts = append(facts, map[string]string{"name": f.name, "value": html.EscapeString(f.value)})
+	}
+
+	section := map[string]interface{}{"facts": facts}
+	if msg.Output != "" {
+		section["text"] = fmt.Sprintf("<pre>%s</pre>", html.EscapeString(msg.Output))
+	}
+
+	return map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Summary(),
+		"title":      msg.Summary(),
+		"themeColor": strings.TrimPrefix(colors[msg.Event], "#"),
+		"sections":   []interface{}{section},
+	}
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxOutput bounds the failure output sent to a webhook, keeping its tail where the error usually is
+const maxOutput = 2000
+
+const redacted = "[REDACTED]"
+
+// each pattern captures the text kept before and after the secret, as groups 1 and 2
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)["']?\s*[:=]\s*)(?:"[^"]*"|'[^']*'|\S+)()`),
+	regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*()`),
+	regexp.MustCompile(`(?i)(://[^:/\s]+:)[^@/\s]+(@)`),
+	regexp.MustCompile(`()\bAKIA[0-9A-Z]{16}\b()`),
+	regexp.MustCompile(`(?s)()-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----()`),
+}
+
+// Redact masks likely credentials in output, along with any of the given known secrets
+func Redact(output string, secrets ...string) string {
+	for _, secret := range secrets {
+		if len(secret) >= 8 {
+			output = strings.ReplaceAll(output, secret, redacted)
+		}
+	}
+
+	for _, pattern := range secretPatterns {
+		output = pattern.ReplaceAllString(output, "${1}"+redacted+"${2}")
+	}
+	return output
+}
+
+func Truncate(output string) string {
+	if len(output) <= maxOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxOutput:]
+}
@@ -138,3 +138,20 @@ func getAvailableProviders() error {
 	}
 	return nil
 }
+
+// scaffoldNames are the names the api knows each provider's terraform scaffold by
+var scaffoldNames = map[string]string{
+	GCP:     "GCP",
+	AWS:     "AWS",
+	AZURE:   "AZURE",
+	EQUINIX: "EQUINIX",
+	KIND:    "KIND",
+}
+
+// ScaffoldName returns the name to fetch a provider's scaffold with from GetProviderScaffold
+func ScaffoldName(provider string) string {
+	if name, ok := scaffoldNames[provider]; ok {
+		return name
+	}
+	return strings.ToUpper(provider)
+}
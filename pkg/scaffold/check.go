@@ -0,0 +1,185 @@
+package scaffold
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rodaine/hclencoder"
+)
+
+type FileAction string
+
+const (
+	FileAdded    FileAction = "added"
+	FileModified FileAction = "modified"
+	FileRemoved  FileAction = "removed"
+)
+
+// FileChange is a file a build would change, with a unified diff of its decrypted contents
+type FileChange struct {
+	Path   string
+	Action FileAction
+	Diff   string
+}
+
+// Decrypter returns the plaintext of a file that may still be encrypted in the working tree
+type Decrypter func(contents []byte) ([]byte, error)
+
+// Scratch copies the workspace at root into a temporary directory with a fresh git repo, so builds can
+// run there without touching the original.  Terraform caches are skipped, they're only ever gitignored
+func Scratch(root string) (string, error) {
+	dir, err := ioutil.TempDir("", "plural-build-check")
+	if err != nil {
+		return "", err
+	}
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".terraform") {
+			return filepath.SkipDir
+		}
+
+		dest := pathing.SanitizeFilepath(filepath.Join(dir, rel))
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(dest, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, dest)
+		default:
+			if err := utils.CopyFile(path, dest); err != nil {
+				return err
+			}
+			return os.Chmod(dest, info.Mode().Perm())
+		}
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// Compare lists the files that differ between the workspace at root and a scratch copy of it,
+// ignoring gitignored files, nonces that are regenerated on every build, and the build fingerprints in
+// build.hcl, which depend on the cli version doing the build
+func Compare(root, scratch string, decrypt Decrypter) ([]*FileChange, error) {
+	files := map[string]bool{}
+	for _, dir := range []string{root, scratch} {
+		listed, err := git.ListFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range listed {
+			if filepath.Base(file) != "NONCE" {
+				files[file] = true
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	changes := make([]*FileChange, 0)
+	for _, path := range paths {
+		before, err := readDecrypted(filepath.Join(root, path), decrypt)
+		if err != nil {
+			return nil, err
+		}
+		after, err := readDecrypted(filepath.Join(scratch, path), decrypt)
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Base(path) == "build.hcl" {
+			if before, err = withoutFingerprint(before); err != nil {
+				return nil, err
+			}
+			if after, err = withoutFingerprint(after); err != nil {
+				return nil, err
+			}
+		}
+
+		change := &FileChange{Path: path}
+		switch {
+		case before == nil && after == nil:
+			continue
+		case before == nil:
+			change.Action = FileAdded
+		case after == nil:
+			change.Action = FileRemoved
+		case string(before) == string(after):
+			continue
+		default:
+			change.Action = FileModified
+		}
+
+		change.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(before)),
+			B:        difflib.SplitLines(string(after)),
+			FromFile: "a/" + path,
+			ToFile:   "b/" + path,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func readDecrypted(path string, decrypt Decrypter) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(path)
+		return []byte(strings.TrimSpace(link)), err
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(contents)
+}
+
+// withoutFingerprint reencodes a build.hcl with its fingerprint cleared
+func withoutFingerprint(contents []byte) ([]byte, error) {
+	if contents == nil {
+		return nil, nil
+	}
+
+	build := &Build{}
+	if err := hcl.Decode(build, string(contents)); err != nil {
+		return nil, err
+	}
+	build.Fingerprint = nil
+	return hclencoder.Encode(build)
+}
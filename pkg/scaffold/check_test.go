@@ -0,0 +1,127 @@
+package scaffold_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, contents := range files {
+		full := filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		assert.NoError(t, ioutil.WriteFile(full, []byte(contents), 0644))
+	}
+}
+
+func gitInit(t *testing.T, root string) {
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = root
+	assert.NoError(t, cmd.Run())
+}
+
+func TestCompare(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	gitInit(t, root)
+	writeFiles(t, root, map[string]string{
+		".gitignore":                         "/**/.terraform\n",
+		"airflow/helm/airflow/values.yaml":   "replicas: 1\n",
+		"airflow/terraform/main.tf":          "module \"airflow\" {}\n",
+		"airflow/terraform/old.tf":           "# removed upstream\n",
+		"airflow/terraform/.terraform/cache": "ignored",
+		"airflow/.plural/NONCE":              "abc",
+	})
+
+	scratch, err := scaffold.Scratch(root)
+	assert.NoError(t, err)
+	defer os.RemoveAll(scratch)
+
+	assert.NoDirExists(t, filepath.Join(scratch, ".git"))
+	assert.NoDirExists(t, filepath.Join(scratch, "airflow", "terraform", ".terraform"))
+	gitInit(t, scratch)
+
+	writeFiles(t, scratch, map[string]string{
+		"airflow/helm/airflow/values.yaml": "replicas: 2\n",
+		"airflow/crds/crd.yaml":            "kind: CustomResourceDefinition\n",
+		"airflow/.plural/NONCE":            "def",
+	})
+	assert.NoError(t, os.Remove(filepath.Join(scratch, "airflow", "terraform", "old.tf")))
+
+	changes, err := scaffold.Compare(root, scratch, func(contents []byte) ([]byte, error) { return contents, nil })
+	assert.NoError(t, err)
+
+	actions := map[string]scaffold.FileAction{}
+	for _, change := range changes {
+		actions[change.Path] = change.Action
+	}
+	assert.Equal(t, map[string]scaffold.FileAction{
+		"airflow/crds/crd.yaml":            scaffold.FileAdded,
+		"airflow/helm/airflow/values.yaml": scaffold.FileModified,
+		"airflow/terraform/old.tf":         scaffold.FileRemoved,
+	}, actions)
+
+	for _, change := range changes {
+		if change.Path == "airflow/helm/airflow/values.yaml" {
+			assert.Contains(t, change.Diff, "-replicas: 1")
+			assert.Contains(t, change.Diff, "+replicas: 2")
+		}
+	}
+}
+
+func TestCompareIgnoresFingerprints(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	gitInit(t, root)
+
+	build := func(dir, token, version string, scaffolds ...*scaffold.Scaffold) {
+		wk := &wkspace.Workspace{
+			Provider:     &provider.KINDProvider{Clust: "test", Proj: "test", Reg: "local"},
+			Installation: &api.Installation{Repository: &api.Repository{Name: "airflow"}},
+			Config:       &config.Config{Email: "me@plural.sh", Token: token},
+			Manifest:     &manifest.ProjectManifest{},
+			Context:      &manifest.Context{},
+		}
+		fingerprint, err := wk.Fingerprint("", version, "theirs")
+		assert.NoError(t, err)
+
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, "airflow"), 0755))
+		b := &scaffold.Build{Metadata: &scaffold.Metadata{Name: "airflow"}, Fingerprint: fingerprint, Scaffolds: scaffolds}
+		assert.NoError(t, b.Flush(dir))
+	}
+	helm := &scaffold.Scaffold{Name: "helm", Path: "helm", Type: scaffold.HELM}
+	build(root, "token", "0.5.0", helm)
+
+	scratch, err := scaffold.Scratch(root)
+	assert.NoError(t, err)
+	defer os.RemoveAll(scratch)
+	gitInit(t, scratch)
+
+	noop := func(contents []byte) ([]byte, error) { return contents, nil }
+
+	// ci builds with its own token and cli version
+	build(scratch, "ci-token", "0.6.0", helm)
+	changes, err := scaffold.Compare(root, scratch, noop)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	build(scratch, "ci-token", "0.6.0", helm, &scaffold.Scaffold{Name: "crds", Path: "crds", Type: scaffold.CRD})
+	changes, err = scaffold.Compare(root, scratch, noop)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, "airflow/build.hcl", changes[0].Path)
+	assert.NotContains(t, changes[0].Diff, "fingerprint")
+}
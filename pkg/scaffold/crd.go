@@ -10,20 +10,31 @@ import (
 	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 	"github.com/pluralsh/plural/pkg/wkspace"
+	"golang.org/x/sync/errgroup"
 )
 
 func (s *Scaffold) buildCrds(wk *wkspace.Workspace) error {
 	utils.Highlight("syncing crds")
+	var group errgroup.Group
+	group.SetLimit(wkspace.MaxConcurrentFetches)
 	for _, chartInst := range wk.Charts {
-		for _, crd := range chartInst.Version.Crds {
-			utils.Highlight(".")
-			if err := writeCrd(s.Root, &crd); err != nil {
-				fmt.Print("\n")
-				return err
-			}
+		for i := range chartInst.Version.Crds {
+			crd := &chartInst.Version.Crds[i]
+			group.Go(func() error {
+				if err := writeCrd(s.Root, crd); err != nil {
+					return err
+				}
+				utils.Highlight(".")
+				return nil
+			})
 		}
 	}
 
+	if err := group.Wait(); err != nil {
+		fmt.Print("\n")
+		return err
+	}
+
 	utils.Success("\u2713\n")
 	return nil
 }
@@ -33,6 +44,11 @@ func writeCrd(path string, crd *api.Crd) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download crd %s: %s", crd.Name, resp.Status)
+	}
 
 	contents, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
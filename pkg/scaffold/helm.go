@@ -224,26 +224,48 @@ func (s *Scaffold) buildChartValues(w *wkspace.Workspace) error {
 		buf.Reset()
 	}
 
-	if err := mergo.Merge(&values, prevVals); err != nil {
-		return err
+	generated := map[string]interface{}{}
+	for name, vals := range values {
+		generated[name] = vals
 	}
-
 	if len(globals) > 0 {
-		values["global"] = globals
+		generated["global"] = globals
 	}
 
-	values["plrl"] = map[string]interface{}{
+	generated["plrl"] = map[string]interface{}{
 		"license": w.Installation.LicenseKey,
 	}
 
-	io, err := yaml.Marshal(values)
+	theirs, err := normalizeValues(generated)
 	if err != nil {
 		fmt.Println("Invalid yaml:")
-		fmt.Println(values)
+		fmt.Println(generated)
 		return err
 	}
 
-	return utils.WriteFile(valuesFile, io)
+	repoRoot, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	repo := w.Installation.Repository.Name
+	basePath := ValuesBasePath(repoRoot, repo)
+	base, err := readValues(basePath)
+	if err != nil {
+		return err
+	}
+	ours, err := readValues(valuesFile)
+	if err != nil {
+		return err
+	}
+
+	merged, conflicts := MergeValues(base, theirs, ours, ValuesStrategy)
+	printConflicts(repo, "values.yaml", conflicts)
+
+	if err := writeValues(valuesFile, merged); err != nil {
+		return err
+	}
+	return writeValues(basePath, theirs)
 }
 
 func prevValues(filename string) (map[string]map[string]interface{}, error) {
@@ -409,3 +431,39 @@ func appVersion(charts []*api.ChartInstallation) string {
 
 	return "0.1.0"
 }
+
+// PinChartVersion sets the version of chart among the dependencies of a repo's built helm chart,
+// returning the Chart.yaml it rewrote, or an empty path if the repo has no such dependency
+func PinChartVersion(root, repo, name, version string) (string, error) {
+	filename := pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo, ChartfileName))
+	if !utils.Exists(filename) {
+		return "", nil
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", errors.ErrorWrap(err, "Failed to read existing Chart.yaml")
+	}
+
+	chart := chart{}
+	if err := yaml.Unmarshal(content, &chart); err != nil {
+		return "", errors.ErrorWrap(err, "Existing Chart.yaml has invalid yaml formatting")
+	}
+
+	pinned := false
+	for i, dep := range chart.Dependencies {
+		if dep.Name == name {
+			chart.Dependencies[i].Version = version
+			pinned = true
+		}
+	}
+	if !pinned {
+		return "", nil
+	}
+
+	chartFile, err := yaml.Marshal(&chart)
+	if err != nil {
+		return "", err
+	}
+	return filename, utils.WriteFile(filename, chartFile)
+}
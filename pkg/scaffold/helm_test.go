@@ -0,0 +1,37 @@
+package scaffold_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinChartVersion(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspace")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, root, map[string]string{
+		"airflow/helm/airflow/Chart.yaml": "apiVersion: v2\nname: airflow\nversion: 0.1.3\ndependencies:\n- name: airflow\n  version: 0.1.0\n  repository: cm://app.plural.sh/cm/airflow\n  condition: airflow.enabled\n",
+	})
+
+	path, err := scaffold.PinChartVersion(root, "airflow", "airflow", "0.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "airflow", "helm", "airflow", "Chart.yaml"), path)
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(contents), "version: 0.2.0\n  repository: cm://app.plural.sh/cm/airflow")
+	assert.Contains(t, string(contents), "version: 0.1.3")
+
+	path, err = scaffold.PinChartVersion(root, "airflow", "postgres", "0.2.0")
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+	path, err = scaffold.PinChartVersion(root, "grafana", "grafana", "1.0.0")
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+}
@@ -0,0 +1,232 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"gopkg.in/yaml.v2"
+)
+
+// ReleaseMapping describes how an existing helm release and its terraform managed resources map onto a
+// plural repo, eg:
+//
+//	release: cert-manager
+//	namespace: cert-manager
+//	chart: cert-manager
+//	context:
+//	  hostname: ingress.hosts.0.host
+//	terraform:
+//	- address: module.cert-manager.aws_iam_role.cert_manager
+//	  id: cert-manager
+type ReleaseMapping struct {
+	Release   string
+	Namespace string
+	// Chart is the subchart of the repo's umbrella chart the release's values belong under
+	Chart string
+	// Context maps context.yaml configuration keys to dotted paths in the release's values
+	Context   map[string]string
+	Terraform []*wkspace.TerraformImport
+}
+
+func ReadReleaseMapping(path string) (*ReleaseMapping, error) {
+	mapping := &ReleaseMapping{}
+	if path == "" {
+		return mapping, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(contents, mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping file %s: %w", path, err)
+	}
+	return mapping, nil
+}
+
+// ChartPath is the repo's generated umbrella chart
+func ChartPath(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))
+}
+
+// Subcharts lists the charts the repo's umbrella chart depends on
+func Subcharts(root, repo string) ([]string, error) {
+	path := pathing.SanitizeFilepath(filepath.Join(ChartPath(root, repo), ChartfileName))
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s has no helm chart, run `plural build --only %s` first", repo, repo)
+	}
+
+	c := chart{}
+	if err := yaml.Unmarshal(contents, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	charts := make([]string, 0, len(c.Dependencies))
+	for _, dep := range c.Dependencies {
+		charts = append(charts, dep.Name)
+	}
+	sort.Strings(charts)
+	return charts, nil
+}
+
+// SelectSubchart picks the subchart a release maps onto: the one asked for, the only one, or the one
+// sharing the release's name
+func SelectSubchart(charts []string, chart, release string) (string, error) {
+	for _, name := range charts {
+		if name == chart || (chart == "" && (len(charts) == 1 || name == release)) {
+			return name, nil
+		}
+	}
+
+	if chart != "" {
+		return "", fmt.Errorf("%s isn't a chart of this repo, must be one of %s", chart, strings.Join(charts, ", "))
+	}
+	return "", fmt.Errorf("can't tell which chart release %s is, pick one of %s with --chart", release, strings.Join(charts, ", "))
+}
+
+// ImportReleaseValues overlays an existing release's values onto the repo's values.yaml under the
+// subchart's key, returning the values before and after.  They read as edits to what plural generates,
+// so the values merge of later builds keeps them
+func ImportReleaseValues(root, repo, chart string, release map[string]interface{}) (before, after map[string]interface{}, err error) {
+	before, err = readValues(pathing.SanitizeFilepath(filepath.Join(ChartPath(root, repo), "values.yaml")))
+	if err != nil {
+		return
+	}
+
+	imported, err := normalizeValues(release)
+	if err != nil {
+		return
+	}
+	after, err = normalizeValues(before)
+	if err != nil {
+		return
+	}
+
+	sub, _ := after[chart].(map[string]interface{})
+	after[chart] = overlayValues(sub, imported)
+	return
+}
+
+// WriteReleaseValues writes the imported values to the repo's values.yaml.  A repo without values from
+// a build to merge against gets the values from before the import as its base, otherwise the next
+// build would see every imported value as a conflict rather than an edit
+func WriteReleaseValues(root, repo string, before, after map[string]interface{}) error {
+	if base := ValuesBasePath(root, repo); !utils.Exists(base) {
+		if err := writeValues(base, before); err != nil {
+			return err
+		}
+	}
+	return writeValues(pathing.SanitizeFilepath(filepath.Join(ChartPath(root, repo), "values.yaml")), after)
+}
+
+// ValueChange is a dotted values path an import adds, changes or removes
+type ValueChange struct {
+	Path   string
+	Action string
+}
+
+// ChangedValues lists the paths that differ between two sets of values, without the values themselves
+// since release values hold secrets under all sorts of keys
+func ChangedValues(before, after map[string]interface{}) []*ValueChange {
+	old, current := map[string]interface{}{}, map[string]interface{}{}
+	flattenValues("", before, old)
+	flattenValues("", after, current)
+
+	changes := make([]*ValueChange, 0)
+	for path, val := range current {
+		prev, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, &ValueChange{Path: path, Action: "added"})
+		case !reflect.DeepEqual(prev, val):
+			changes = append(changes, &ValueChange{Path: path, Action: "changed"})
+		}
+	}
+	for path := range old {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, &ValueChange{Path: path, Action: "removed"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// flattenValues indexes the leaves of values by their dotted path, lists are treated as leaves
+func flattenValues(prefix string, values map[string]interface{}, into map[string]interface{}) {
+	for key, val := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if child, ok := val.(map[string]interface{}); ok && len(child) > 0 {
+			flattenValues(path, child, into)
+			continue
+		}
+		into[path] = val
+	}
+}
+
+func overlayValues(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+
+	for k, v := range src {
+		srcMap, srcOk := v.(map[string]interface{})
+		dstMap, dstOk := dst[k].(map[string]interface{})
+		if srcOk && dstOk {
+			dst[k] = overlayValues(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// ContextValues looks up the context.yaml configuration a mapping file reads from a release's values.
+// Paths are dotted, indexing into lists by position
+func ContextValues(values map[string]interface{}, paths map[string]string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for key, path := range paths {
+		val, ok := lookupValue(values, path)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't set in the release's values, so %s can't be read from it", path, key)
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+func lookupValue(values interface{}, path string) (interface{}, bool) {
+	val := values
+	for _, part := range strings.Split(path, ".") {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			child, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			val = child
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			val = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return val, true
+}
@@ -0,0 +1,181 @@
+package scaffold_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+const umbrellaChart = `apiVersion: v2
+name: cert-manager
+version: 0.1.0
+dependencies:
+- name: cert-manager
+  version: 1.8.0
+  repository: cm://app.plural.sh/cert-manager/cert-manager
+  condition: cert-manager.enabled
+- name: issuers
+  version: 0.1.0
+  repository: cm://app.plural.sh/cert-manager/issuers
+  condition: issuers.enabled
+`
+
+const umbrellaValues = `cert-manager:
+  enabled: true
+  installCRDs: true
+  serviceAccount:
+    create: true
+issuers:
+  enabled: true
+`
+
+const releaseMapping = `release: cert-manager-legacy
+namespace: infra
+context:
+  email: ingressShim.defaultIssuerEmail
+  solver: solvers.0.dns
+terraform:
+- address: module.cert-manager.aws_iam_role.cert_manager
+  id: cert-manager
+`
+
+func TestImportReleaseValues(t *testing.T) {
+	root, err := ioutil.TempDir("", "release")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	chart := scaffold.ChartPath(root, "cert-manager")
+	assert.NoError(t, os.MkdirAll(chart, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(chart, "Chart.yaml"), []byte(umbrellaChart), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(chart, "values.yaml"), []byte(umbrellaValues), 0644))
+
+	charts, err := scaffold.Subcharts(root, "cert-manager")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cert-manager", "issuers"}, charts)
+
+	release := map[string]interface{}{
+		"serviceAccount": map[string]interface{}{"name": "cert-manager"},
+		"replicaCount":   float64(2),
+	}
+	before, after, err := scaffold.ImportReleaseValues(root, "cert-manager", "cert-manager", release)
+	assert.NoError(t, err)
+	assert.Equal(t, true, before["cert-manager"].(map[string]interface{})["installCRDs"])
+	assert.Equal(t, map[string]interface{}{
+		"cert-manager": map[string]interface{}{
+			"enabled":        true,
+			"installCRDs":    true,
+			"replicaCount":   2,
+			"serviceAccount": map[string]interface{}{"create": true, "name": "cert-manager"},
+		},
+		"issuers": map[string]interface{}{"enabled": true},
+	}, after)
+
+	assert.Equal(t, []*scaffold.ValueChange{
+		{Path: "cert-manager.replicaCount", Action: "added"},
+		{Path: "cert-manager.serviceAccount.name", Action: "added"},
+	}, scaffold.ChangedValues(before, after))
+
+	assert.NoError(t, scaffold.WriteReleaseValues(root, "cert-manager", before, after))
+	base, err := ioutil.ReadFile(scaffold.ValuesBasePath(root, "cert-manager"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(base), "replicaCount")
+	values, err := ioutil.ReadFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(values), "replicaCount: 2")
+
+	// the base from a build is kept, the import doesn't change what plural generated
+	assert.NoError(t, scaffold.WriteReleaseValues(root, "cert-manager", after, after))
+	rewritten, err := ioutil.ReadFile(scaffold.ValuesBasePath(root, "cert-manager"))
+	assert.NoError(t, err)
+	assert.Equal(t, base, rewritten)
+
+	_, err = scaffold.Subcharts(root, "airflow")
+	assert.Error(t, err)
+}
+
+func TestSelectSubchart(t *testing.T) {
+	tests := []struct {
+		name     string
+		charts   []string
+		chart    string
+		release  string
+		expected string
+		err      bool
+	}{
+		{
+			name:     `test the only chart is picked`,
+			charts:   []string{"postgres-operator"},
+			release:  "postgres",
+			expected: "postgres-operator",
+		},
+		{
+			name:     `test the chart named like the release is picked`,
+			charts:   []string{"cert-manager", "issuers"},
+			release:  "cert-manager",
+			expected: "cert-manager",
+		},
+		{
+			name:     `test an explicit chart wins`,
+			charts:   []string{"cert-manager", "issuers"},
+			chart:    "issuers",
+			release:  "cert-manager",
+			expected: "issuers",
+		},
+		{
+			name:    `test ambiguous releases fail`,
+			charts:  []string{"cert-manager", "issuers"},
+			release: "legacy",
+			err:     true,
+		},
+		{
+			name:    `test unknown charts fail`,
+			charts:  []string{"cert-manager"},
+			chart:   "issuers",
+			release: "cert-manager",
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chart, err := scaffold.SelectSubchart(test.charts, test.chart, test.release)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, chart)
+		})
+	}
+}
+
+func TestReleaseMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapping")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mapping.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(releaseMapping), 0644))
+
+	mapping, err := scaffold.ReadReleaseMapping(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "cert-manager-legacy", mapping.Release)
+	assert.Equal(t, "infra", mapping.Namespace)
+	assert.Equal(t, []*wkspace.TerraformImport{{Address: "module.cert-manager.aws_iam_role.cert_manager", Id: "cert-manager"}}, mapping.Terraform)
+
+	values := map[string]interface{}{
+		"ingressShim": map[string]interface{}{"defaultIssuerEmail": "ops@acme.com"},
+		"solvers":     []interface{}{map[string]interface{}{"dns": "route53"}},
+	}
+	conf, err := scaffold.ContextValues(values, mapping.Context)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"email": "ops@acme.com", "solver": "route53"}, conf)
+
+	_, err = scaffold.ContextValues(values, map[string]string{"email": "solvers.1.dns"})
+	assert.Error(t, err)
+}
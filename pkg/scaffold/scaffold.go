@@ -1,9 +1,12 @@
 package scaffold
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pluralsh/plural/pkg/executor"
 	"github.com/pluralsh/plural/pkg/utils"
@@ -27,8 +30,10 @@ type Metadata struct {
 }
 
 type Build struct {
-	Metadata  *Metadata   `hcl:"metadata"`
-	Scaffolds []*Scaffold `hcl:"scaffold"`
+	Metadata *Metadata `hcl:"metadata"`
+	// Fingerprint is of the inputs to the last successful build, and is cleared while a build runs
+	Fingerprint *wkspace.Fingerprint `hcl:"fingerprint" hcle:"omitempty"`
+	Scaffolds   []*Scaffold          `hcl:"scaffold"`
 }
 
 const (
@@ -52,10 +57,10 @@ func Scaffolds(wk *wkspace.Workspace) (*Build, error) {
 		return def, nil
 	}
 
-	return merge(build, def), nil
+	return merge(build, def)
 }
 
-func merge(build *Build, base *Build) *Build {
+func merge(build *Build, base *Build) (*Build, error) {
 	byName := make(map[string]*Scaffold)
 
 	for _, scaffold := range build.Scaffolds {
@@ -87,7 +92,7 @@ func merge(build *Build, base *Build) *Build {
 
 	sorted, ok := graph.Topsort()
 	if !ok {
-		panic("scaffold cycle created")
+		return nil, fmt.Errorf("the order of scaffolds %s in %s/build.hcl conflicts with the default order, reorder or remove them", utils.FormatCycle(graph.Cycle()), base.Metadata.Name)
 	}
 
 	scaffolds := []*Scaffold{}
@@ -96,7 +101,7 @@ func merge(build *Build, base *Build) *Build {
 	}
 	build.Scaffolds = scaffolds
 
-	return build
+	return build, nil
 }
 
 func mergePreflights(new, old *Scaffold) {
@@ -107,11 +112,27 @@ func mergePreflights(new, old *Scaffold) {
 
 	for _, preflight := range new.Preflight {
 		if prev, ok := byName[preflight.Name]; ok {
-			preflight.Sha = prev.Sha
+			preflight.Inherit(prev)
 		}
 	}
 }
 
+// Stale lists the reasons the build has to run again, which is empty if the inputs haven't changed since
+// the last successful build and everything it generated is still there
+func (b *Build) Stale(root string, fingerprint *wkspace.Fingerprint) []string {
+	reasons := fingerprint.Changes(b.Fingerprint)
+	if len(reasons) > 0 {
+		return reasons
+	}
+
+	for _, s := range b.Scaffolds {
+		if !utils.Exists(pathing.SanitizeFilepath(filepath.Join(root, b.Metadata.Name, s.Path))) {
+			reasons = append(reasons, fmt.Sprintf("%s is missing", s.Path))
+		}
+	}
+	return reasons
+}
+
 func (b *Build) Flush(root string) error {
 	io, err := hclencoder.Encode(&b)
 	if err != nil {
@@ -135,7 +156,8 @@ func (s *Scaffold) Execute(wk *wkspace.Workspace, force bool) error {
 			preflight.Sha = ""
 		}
 
-		sha, err := preflight.Execute(s.Root, ignore)
+		preflight.Repo = wk.Installation.Repository.Name
+		sha, err := preflight.Execute(context.Background(), s.Root, ignore)
 		if err != nil {
 			return err
 		}
@@ -158,12 +180,26 @@ func (s *Scaffold) executeType(wk *wkspace.Workspace) error {
 	}
 }
 
-func (b *Build) Execute(wk *wkspace.Workspace, force bool) error {
+func (s *Scaffold) event(typ executor.EventType, repo string, start time.Time, err error) *executor.Event {
+	event := &executor.Event{Type: typ, Repo: repo, Step: s.Name, Command: s.Type}
+	if typ != executor.StepStarted {
+		event.Duration = time.Since(start).Seconds()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// Execute runs every scaffold of the build, recording the fingerprint of its inputs once all of them succeed
+func (b *Build) Execute(wk *wkspace.Workspace, fingerprint *wkspace.Fingerprint, force bool) error {
 	root, err := git.Root()
 	if err != nil {
 		return err
 	}
 
+	b.Fingerprint = nil
+
 	for _, s := range b.Scaffolds {
 		path := pathing.SanitizeFilepath(filepath.Join(root, b.Metadata.Name, s.Path))
 		if err := os.MkdirAll(path, os.ModePerm); err != nil {
@@ -171,11 +207,16 @@ func (b *Build) Execute(wk *wkspace.Workspace, force bool) error {
 			return err
 		}
 		s.Root = path
+		start := time.Now()
+		executor.Emit(s.event(executor.StepStarted, b.Metadata.Name, start, nil))
 		if err := s.Execute(wk, force); err != nil {
+			executor.Emit(s.event(executor.StepFailed, b.Metadata.Name, start, err))
 			b.Flush(root)
 			return err
 		}
+		executor.Emit(s.event(executor.StepSucceeded, b.Metadata.Name, start, nil))
 	}
 
+	b.Fingerprint = fingerprint
 	return b.Flush(root)
 }
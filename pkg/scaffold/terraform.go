@@ -3,18 +3,21 @@ package scaffold
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
 
 	"golang.org/x/mod/semver"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/template"
 	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 	"github.com/pluralsh/plural/pkg/wkspace"
 )
@@ -22,10 +25,6 @@ import (
 const moduleTemplate = `module "{{ .Values.name }}" {
   source = "{{ .Values.path }}"
 
-### BEGIN MANUAL SECTION <<{{ .Values.name }}>>
-{{ .Values.Manual }}
-### END MANUAL SECTION <<{{ .Values.name }}>>
-
 {{ .Values.conf | nindent 2 }}
 {{ range $key, $val := .Values.deps }}
   {{ $key }} = module.{{ $val }}
@@ -42,9 +41,9 @@ const outputTemplate = `output "{{ .Name }}" {
 const helpDoc = `
 ############## Helpful Hints ###################
 
-# Any configuration updates should be done in this file in a manual section. You can also create files alongside this one, 
-# and plural build will preserve them.  That's the ideal strategy if you want to add additional resources like a sql instance
-# or vpn gateway.
+# You can add attributes and blocks anywhere in this file and plural build will preserve them.  Changing an attribute plural
+# generates is reported as a conflict on the next build, resolved with plural build --strategy.  You can also create files
+# alongside this one, which is the ideal strategy if you want to add additional resources like a sql instance or vpn gateway.
 
 # The submodule folders within this directory are generated by plural build and we cannot guarantee a change in 
 # them will be merged properly.
@@ -142,7 +141,6 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 		} else {
 			module["deps"] = map[string]interface{}{}
 		}
-		module["Manual"] = manualSection(contents, tf.Name)
 
 		var moduleBuf bytes.Buffer
 		moduleBuf.Grow(1024)
@@ -159,7 +157,7 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 		buf.Reset()
 	}
 
-	if err := utils.WriteFile(mainFile, []byte(strings.Join(modules, "\n\n"))); err != nil {
+	if err := scaffold.mergeMain(repo.Name, mainFile, contents, strings.Join(modules, "\n\n")); err != nil {
 		return err
 	}
 
@@ -179,20 +177,28 @@ func (scaffold *Scaffold) handleTerraform(wk *wkspace.Workspace) error {
 func (scaffold *Scaffold) untarModules(wk *wkspace.Workspace) error {
 	length := len(wk.Terraform)
 	utils.Highlight("unpacking %d %s", len(wk.Terraform), utils.Pluralize("module", "modules", length))
+	var group errgroup.Group
+	group.SetLimit(wkspace.MaxConcurrentFetches)
 	for _, tfInst := range wk.Terraform {
 		tf := tfInst.Terraform
 		v := tfInst.Version
-		path := pathing.SanitizeFilepath(filepath.Join(scaffold.Root, tf.Name))
-		if err := os.MkdirAll(path, os.ModePerm); err != nil {
-			fmt.Print("\n")
-			return err
-		}
+		group.Go(func() error {
+			path := pathing.SanitizeFilepath(filepath.Join(scaffold.Root, tf.Name))
+			if err := os.MkdirAll(path, os.ModePerm); err != nil {
+				return err
+			}
 
-		if err := untar(v, tf, path); err != nil {
-			fmt.Print("\n")
-			return err
-		}
-		fmt.Print(".")
+			if err := untar(v, tf, path); err != nil {
+				return err
+			}
+			fmt.Print(".")
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		fmt.Print("\n")
+		return err
 	}
 
 	utils.Success("\u2713\n")
@@ -226,23 +232,47 @@ func (scaffold *Scaffold) buildOutputs(wk *wkspace.Workspace) error {
 	return utils.WriteFile(outputFile, buf.Bytes())
 }
 
-func untar(v *api.Version, tf *api.Terraform, dir string) error {
-	resp, err := http.Get(v.Package)
+// mergeMain merges freshly generated terraform into main.tf, keeping what it generated as the base
+// for the next merge
+func (scaffold *Scaffold) mergeMain(repo, mainFile, current, generated string) error {
+	root, err := git.Root()
 	if err != nil {
 		return err
 	}
 
-	return utils.Untar(resp.Body, dir, tf.Name)
+	basePath := TerraformBasePath(root, repo)
+	base, err := ioutil.ReadFile(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	merged, conflicts, err := MergeTerraform(base, []byte(generated), []byte(current), ValuesStrategy)
+	if err != nil {
+		return err
+	}
+	printConflicts(repo, "main.tf", conflicts)
+
+	if err := utils.WriteFile(mainFile, merged); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(basePath), 0755); err != nil {
+		return err
+	}
+	return utils.WriteFile(basePath, hclwrite.Format([]byte(generated)))
 }
 
-func manualSection(contents, name string) string {
-	re := regexp.MustCompile(fmt.Sprintf(`(?s)### BEGIN MANUAL SECTION <<%s>>(.*)### END MANUAL SECTION <<%s>>`, name, name))
-	matches := re.FindStringSubmatch(contents)
-	if len(matches) > 0 {
-		return strings.TrimSpace(matches[1])
+func untar(v *api.Version, tf *api.Terraform, dir string) error {
+	resp, err := http.Get(v.Package)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download terraform module %s: %s", tf.Name, resp.Status)
 	}
 
-	return ""
+	return utils.Untar(resp.Body, dir, tf.Name)
 }
 
 func buildTfSecrets(installations []*api.TerraformInstallation) []string {
@@ -0,0 +1,223 @@
+package scaffold
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// TerraformBasePath is where the main.tf generated by a repo's last build is kept, as the common
+// ancestor for merging the next build's terraform with any edits to main.tf
+func TerraformBasePath(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, ".plural", "main.base.tf"))
+}
+
+// MergeTerraform merges newly generated terraform into the current main.tf, attribute by attribute.
+// Attributes and blocks added anywhere in current are kept, generated attributes that were edited
+// are conflicts the strategy resolves, and whatever the templates no longer generate is pruned.  An
+// empty base, as in workspaces built before bases were kept, takes every generated attribute
+func MergeTerraform(base, generated, current []byte, strategy Strategy) ([]byte, []*Conflict, error) {
+	if len(strings.TrimSpace(string(current))) == 0 {
+		return hclwrite.Format(generated), []*Conflict{}, nil
+	}
+
+	cur, err := parseTerraform(current, "main.tf")
+	if err != nil {
+		return nil, nil, err
+	}
+	gen, err := parseTerraform(generated, "generated main.tf")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var baseBody *hclwrite.Body
+	if len(base) > 0 {
+		b, err := parseTerraform(base, "main.tf base")
+		if err != nil {
+			return nil, nil, err
+		}
+		baseBody = b.Body()
+	}
+
+	m := &tfMerger{strategy: strategy, conflicts: make([]*Conflict, 0), hasBase: baseBody != nil}
+	m.mergeBody("", baseBody, gen.Body(), cur.Body())
+	sort.Slice(m.conflicts, func(i, j int) bool { return m.conflicts[i].Path < m.conflicts[j].Path })
+	return hclwrite.Format(cur.Bytes()), m.conflicts, nil
+}
+
+func parseTerraform(src []byte, filename string) (*hclwrite.File, error) {
+	file, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("failed to parse %s, fix or delete it to regenerate it: %w", filename, diags)
+	}
+	return file, nil
+}
+
+type tfMerger struct {
+	strategy  Strategy
+	conflicts []*Conflict
+	hasBase   bool
+}
+
+func (m *tfMerger) conflict(path string) bool {
+	m.conflicts = append(m.conflicts, &Conflict{Path: path, Kept: m.strategy})
+	return m.strategy == StrategyTheirs
+}
+
+func (m *tfMerger) mergeBody(path string, base, gen, cur *hclwrite.Body) {
+	m.mergeAttributes(path, base, gen, cur)
+
+	baseBlocks := blocksByKey(base)
+	curBlocks := blocksByKey(cur)
+	for _, key := range blockKeys(gen) {
+		for _, block := range blocksByKey(gen)[key] {
+			var baseBlock *hclwrite.Block
+			if blocks := baseBlocks[key]; len(blocks) > 0 {
+				baseBlock, baseBlocks[key] = blocks[0], blocks[1:]
+			}
+
+			blocks := curBlocks[key]
+			if len(blocks) == 0 {
+				cur.AppendNewline()
+				cur.AppendUnstructuredTokens(copyTokens(block.BuildTokens(nil)))
+				continue
+			}
+			curBlocks[key] = blocks[1:]
+
+			var baseBody *hclwrite.Body
+			if baseBlock != nil {
+				baseBody = baseBlock.Body()
+			}
+			m.mergeBody(join(path, key), baseBody, block.Body(), blocks[0].Body())
+		}
+	}
+
+	// blocks the templates generated last time but no longer do are pruned, unless they were edited
+	for key, blocks := range baseBlocks {
+		for i, block := range blocks {
+			remaining := curBlocks[key]
+			if i >= len(remaining) {
+				break
+			}
+			if tokensEqual(block.BuildTokens(nil), remaining[i].BuildTokens(nil)) || m.conflict(join(path, key)) {
+				cur.RemoveBlock(remaining[i])
+			}
+		}
+	}
+}
+
+func (m *tfMerger) mergeAttributes(path string, base, gen, cur *hclwrite.Body) {
+	genAttrs := gen.Attributes()
+	names := make([]string, 0, len(genAttrs))
+	for name := range genAttrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		genTokens := genAttrs[name].Expr().BuildTokens(nil)
+		curAttr := cur.GetAttribute(name)
+		baseAttr := getAttribute(base, name)
+
+		switch {
+		case curAttr == nil && baseAttr != nil && m.hasBase:
+			// a generated attribute was deleted
+			if m.conflict(join(path, name)) {
+				cur.SetAttributeRaw(name, copyTokens(genTokens))
+			}
+		case curAttr == nil:
+			cur.SetAttributeRaw(name, copyTokens(genTokens))
+		case tokensEqual(curAttr.Expr().BuildTokens(nil), genTokens):
+		case !m.hasBase || (baseAttr != nil && tokensEqual(curAttr.Expr().BuildTokens(nil), baseAttr.Expr().BuildTokens(nil))):
+			cur.SetAttributeRaw(name, copyTokens(genTokens))
+		case baseAttr != nil && tokensEqual(genTokens, baseAttr.Expr().BuildTokens(nil)):
+			// the user overrode a generated attribute the templates haven't changed
+			m.conflicts = append(m.conflicts, &Conflict{Path: join(path, name), Kept: StrategyOurs})
+		default:
+			if m.conflict(join(path, name)) {
+				cur.SetAttributeRaw(name, copyTokens(genTokens))
+			}
+		}
+	}
+
+	if base == nil {
+		return
+	}
+
+	for name, baseAttr := range base.Attributes() {
+		if _, ok := genAttrs[name]; ok {
+			continue
+		}
+		curAttr := cur.GetAttribute(name)
+		if curAttr == nil {
+			continue
+		}
+		if tokensEqual(curAttr.Expr().BuildTokens(nil), baseAttr.Expr().BuildTokens(nil)) || m.conflict(join(path, name)) {
+			cur.RemoveAttribute(name)
+		}
+	}
+}
+
+func getAttribute(body *hclwrite.Body, name string) *hclwrite.Attribute {
+	if body == nil {
+		return nil
+	}
+	return body.GetAttribute(name)
+}
+
+func blockKey(block *hclwrite.Block) string {
+	return strings.Join(append([]string{block.Type()}, block.Labels()...), ".")
+}
+
+func blocksByKey(body *hclwrite.Body) map[string][]*hclwrite.Block {
+	blocks := map[string][]*hclwrite.Block{}
+	if body == nil {
+		return blocks
+	}
+	for _, block := range body.Blocks() {
+		key := blockKey(block)
+		blocks[key] = append(blocks[key], block)
+	}
+	return blocks
+}
+
+// blockKeys lists the distinct block keys of a body in the order they first appear
+func blockKeys(body *hclwrite.Body) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, block := range body.Blocks() {
+		if key := blockKey(block); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// tokensEqual compares expressions ignoring formatting
+func tokensEqual(a, b hclwrite.Tokens) bool {
+	return strings.Join(strings.Fields(string(a.Bytes())), " ") == strings.Join(strings.Fields(string(b.Bytes())), " ")
+}
+
+// copyTokens detaches tokens from the file they were parsed from, so they can be added to another
+func copyTokens(tokens hclwrite.Tokens) hclwrite.Tokens {
+	copied := make(hclwrite.Tokens, len(tokens))
+	for i, token := range tokens {
+		t := *token
+		t.Bytes = append([]byte{}, token.Bytes...)
+		copied[i] = &t
+	}
+	return copied
+}
@@ -0,0 +1,147 @@
+package scaffold_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/stretchr/testify/assert"
+)
+
+const tfBase = `terraform {
+  backend "gcs" {
+    bucket = "state"
+  }
+}
+
+module "airflow" {
+  source       = "./airflow"
+  cluster_name = "prod"
+  node_pool    = "small"
+  legacy       = true
+}
+`
+
+func TestMergeTerraform(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		generated string
+		current   string
+		strategy  scaffold.Strategy
+		expected  string
+		conflicts []string
+		kept      []scaffold.Strategy
+	}{
+		{
+			name: `test user attributes and blocks are preserved while generated ones update`,
+			base: tfBase,
+			generated: `terraform {
+  backend "gcs" {
+    bucket = "state"
+  }
+}
+
+module "airflow" {
+  source       = "./airflow"
+  cluster_name = "prod"
+  node_pool    = "large"
+}
+`,
+			current: `terraform {
+  backend "gcs" {
+    bucket = "state"
+  }
+}
+
+module "airflow" {
+  source       = "./airflow"
+  cluster_name = "prod"
+  node_pool    = "small"
+  legacy       = true
+  extra        = "mine"
+}
+
+resource "google_sql_database" "extra" {
+  name = "extra"
+}
+`,
+			strategy: scaffold.StrategyTheirs,
+			expected: `terraform {
+  backend "gcs" {
+    bucket = "state"
+  }
+}
+
+module "airflow" {
+  source       = "./airflow"
+  cluster_name = "prod"
+  node_pool    = "large"
+  extra        = "mine"
+}
+
+resource "google_sql_database" "extra" {
+  name = "extra"
+}
+`,
+			conflicts: []string{},
+		},
+		{
+			name:      `test overriding a generated attribute is reported and kept`,
+			base:      tfBase,
+			generated: tfBase,
+			current:   replace(tfBase, `"prod"`, `"staging"`),
+			strategy:  scaffold.StrategyTheirs,
+			expected:  replace(tfBase, `"prod"`, `"staging"`),
+			conflicts: []string{"module.airflow.cluster_name"},
+			kept:      []scaffold.Strategy{scaffold.StrategyOurs},
+		},
+		{
+			name:      `test conflicting changes follow the strategy`,
+			base:      tfBase,
+			generated: replace(tfBase, `"small"`, `"large"`),
+			current:   replace(tfBase, `"small"`, `"custom"`),
+			strategy:  scaffold.StrategyOurs,
+			expected:  replace(tfBase, `"small"`, `"custom"`),
+			conflicts: []string{"module.airflow.node_pool"},
+			kept:      []scaffold.Strategy{scaffold.StrategyOurs},
+		},
+		{
+			name:      `test new generated blocks are added`,
+			base:      tfBase,
+			generated: tfBase + "\nmodule \"postgres\" {\n  source = \"./postgres\"\n}\n",
+			current:   tfBase,
+			strategy:  scaffold.StrategyTheirs,
+			expected:  tfBase + "\nmodule \"postgres\" {\n  source = \"./postgres\"\n}\n",
+			conflicts: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflicts, err := scaffold.MergeTerraform([]byte(test.base), []byte(test.generated), []byte(test.current), test.strategy)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(merged))
+
+			paths := []string{}
+			kept := []scaffold.Strategy{}
+			for _, conflict := range conflicts {
+				paths = append(paths, conflict.Path)
+				kept = append(kept, conflict.Kept)
+			}
+			assert.Equal(t, test.conflicts, paths)
+			if test.kept != nil {
+				assert.Equal(t, test.kept, kept)
+			}
+		})
+	}
+}
+
+func TestMergeTerraformInvalid(t *testing.T) {
+	_, _, err := scaffold.MergeTerraform(nil, []byte(tfBase), []byte("module \"airflow\" {\n"), scaffold.StrategyTheirs)
+	assert.Error(t, err)
+}
+
+func replace(s, old, new string) string {
+	return strings.Replace(s, old, new, 1)
+}
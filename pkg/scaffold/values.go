@@ -0,0 +1,185 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+// Strategy picks the side that wins when a user edit to values.yaml conflicts with a change to
+// the generated values
+type Strategy string
+
+const (
+	// StrategyOurs keeps the edit made to values.yaml
+	StrategyOurs Strategy = "ours"
+	// StrategyTheirs takes the newly generated value
+	StrategyTheirs Strategy = "theirs"
+)
+
+// ValuesStrategy is how builds resolve values conflicts, taking the generated values by default like
+// builds always have
+var ValuesStrategy = StrategyTheirs
+
+func ParseStrategy(strategy string) (Strategy, error) {
+	switch s := Strategy(strategy); s {
+	case StrategyOurs, StrategyTheirs:
+		return s, nil
+	default:
+		return "", fmt.Errorf("unsupported merge strategy %s, must be one of ours or theirs", strategy)
+	}
+}
+
+// Conflict is a values path that was both edited in values.yaml and changed by the templates
+type Conflict struct {
+	Path string
+	Kept Strategy
+}
+
+// ValuesBasePath is where the values generated by a repo's last build are kept, as the common
+// ancestor for merging the next build's values with any edits to values.yaml
+func ValuesBasePath(root, repo string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, repo, ".plural", "values.base.yaml"))
+}
+
+// MergeValues does a three-way merge of generated helm values.  Edits made to ours since base are
+// kept, changes the templates made since base are taken, keys the templates no longer generate are
+// pruned, and where both sides changed the same key the strategy decides
+func MergeValues(base, theirs, ours map[string]interface{}, strategy Strategy) (map[string]interface{}, []*Conflict) {
+	conflicts := make([]*Conflict, 0)
+	merged := mergeMaps("", base, theirs, ours, strategy, &conflicts)
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	return merged, conflicts
+}
+
+func mergeMaps(prefix string, base, theirs, ours map[string]interface{}, strategy Strategy, conflicts *[]*Conflict) map[string]interface{} {
+	keys := map[string]bool{}
+	for _, m := range []map[string]interface{}{base, theirs, ours} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+
+	merged := make(map[string]interface{})
+	for key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		b, inBase := base[key]
+		t, inTheirs := theirs[key]
+		o, inOurs := ours[key]
+		same := func(x, y interface{}, inX, inY bool) bool {
+			return inX == inY && reflect.DeepEqual(x, y)
+		}
+
+		val, keep := t, inTheirs
+		switch {
+		case same(o, t, inOurs, inTheirs), same(o, b, inOurs, inBase):
+		case same(t, b, inTheirs, inBase):
+			val, keep = o, inOurs
+		default:
+			bm, bok := b.(map[string]interface{})
+			tm, tok := t.(map[string]interface{})
+			om, ook := o.(map[string]interface{})
+			if (bok || !inBase) && (tok || !inTheirs) && (ook || !inOurs) {
+				val, keep = mergeMaps(path, bm, tm, om, strategy, conflicts), true
+				break
+			}
+
+			*conflicts = append(*conflicts, &Conflict{Path: path, Kept: strategy})
+			if strategy == StrategyOurs {
+				val, keep = o, inOurs
+			}
+		}
+
+		if keep {
+			merged[key] = val
+		}
+	}
+	return merged
+}
+
+// readValues reads a values file, returning an empty map if it doesn't exist
+func readValues(path string) (map[string]interface{}, error) {
+	if !utils.Exists(path) {
+		return map[string]interface{}{}, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals map[interface{}]interface{}
+	if err := yaml.Unmarshal(contents, &vals); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return stringKeys(vals).(map[string]interface{}), nil
+}
+
+// normalizeValues round trips values through yaml, so they compare equal to the same values read back
+// from a file
+func normalizeValues(values interface{}) (map[string]interface{}, error) {
+	contents, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	var vals map[interface{}]interface{}
+	if err := yaml.Unmarshal(contents, &vals); err != nil {
+		return nil, err
+	}
+	return stringKeys(vals).(map[string]interface{}), nil
+}
+
+// stringKeys converts the maps yaml decodes to string keyed ones, leaving scalars untouched
+func stringKeys(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			result[fmt.Sprint(k)] = stringKeys(child)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, child := range v {
+			result[i] = stringKeys(child)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func writeValues(path string, values map[string]interface{}) error {
+	io, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return utils.WriteFile(path, io)
+}
+
+func printConflicts(repo, file string, conflicts []*Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	utils.Warn("%s %s has edits conflicting with what plural generates, resolve them with --strategy:\n", repo, file)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "  %s (kept %s)\n", conflict.Path, conflict.Kept)
+	}
+}
@@ -0,0 +1,82 @@
+package scaffold_test
+
+import (
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/scaffold"
+	"github.com/stretchr/testify/assert"
+)
+
+type values = map[string]interface{}
+
+func TestMergeValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      values
+		theirs    values
+		ours      values
+		strategy  scaffold.Strategy
+		expected  values
+		conflicts []string
+	}{
+		{
+			name:     `test user edits are preserved`,
+			base:     values{"airflow": values{"replicas": 1, "image": "airflow:2.1"}},
+			theirs:   values{"airflow": values{"replicas": 1, "image": "airflow:2.2"}},
+			ours:     values{"airflow": values{"replicas": 3, "image": "airflow:2.1", "extra": true}},
+			strategy: scaffold.StrategyTheirs,
+			expected: values{"airflow": values{"replicas": 3, "image": "airflow:2.2", "extra": true}},
+		},
+		{
+			name:     `test removed template keys are pruned`,
+			base:     values{"airflow": values{"replicas": 1, "legacy": values{"enabled": true}}},
+			theirs:   values{"airflow": values{"replicas": 1}},
+			ours:     values{"airflow": values{"replicas": 1, "legacy": values{"enabled": true}}},
+			strategy: scaffold.StrategyTheirs,
+			expected: values{"airflow": values{"replicas": 1}},
+		},
+		{
+			name:      `test conflicts keep theirs`,
+			base:      values{"airflow": values{"image": "airflow:2.1"}},
+			theirs:    values{"airflow": values{"image": "airflow:2.2"}},
+			ours:      values{"airflow": values{"image": "airflow:custom"}},
+			strategy:  scaffold.StrategyTheirs,
+			expected:  values{"airflow": values{"image": "airflow:2.2"}},
+			conflicts: []string{"airflow.image"},
+		},
+		{
+			name:      `test conflicts keep ours`,
+			base:      values{"airflow": values{"image": "airflow:2.1", "old": "x"}},
+			theirs:    values{"airflow": values{"image": "airflow:2.2"}},
+			ours:      values{"airflow": values{"image": "airflow:custom", "old": "y"}},
+			strategy:  scaffold.StrategyOurs,
+			expected:  values{"airflow": values{"image": "airflow:custom", "old": "y"}},
+			conflicts: []string{"airflow.image", "airflow.old"},
+		},
+		{
+			name:     `test without a base keeps keys only in values.yaml`,
+			base:     values{},
+			theirs:   values{"airflow": values{"image": "airflow:2.2"}},
+			ours:     values{"airflow": values{"image": "airflow:2.2", "extra": true}},
+			strategy: scaffold.StrategyTheirs,
+			expected: values{"airflow": values{"image": "airflow:2.2", "extra": true}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflicts := scaffold.MergeValues(test.base, test.theirs, test.ours, test.strategy)
+			assert.Equal(t, test.expected, merged)
+
+			paths := []string{}
+			for _, conflict := range conflicts {
+				paths = append(paths, conflict.Path)
+				assert.Equal(t, test.strategy, conflict.Kept)
+			}
+			if test.conflicts == nil {
+				test.conflicts = []string{}
+			}
+			assert.Equal(t, test.conflicts, paths)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"golang.org/x/sync/errgroup"
+)
+
+type packages struct {
+	Charts    []*api.ChartInstallation
+	Terraform []*api.TerraformInstallation
+}
+
+func scaffoldKey(name, version string) string {
+	return fmt.Sprintf("%s@%s", name, version)
+}
+
+// Capture snapshots everything a build of the workspace needs from the api: its installations, their
+// chart and terraform packages, the provider's terraform scaffolds, and every crd and terraform module
+func Capture(client api.Client, store *Store, prov string) error {
+	installations, err := client.GetInstallations()
+	if err != nil {
+		return err
+	}
+	if store.Index.Installations, err = store.PutJSON(installations); err != nil {
+		return err
+	}
+
+	cache := wkspace.NewPackageCache(client)
+	if err := cache.Prefetch(installations); err != nil {
+		return err
+	}
+
+	blobs := map[string]bool{}
+	versions := map[string]bool{}
+	for _, installation := range installations {
+		charts, tfs, err := cache.GetPackageInstallations(installation.Repository.Id)
+		if err != nil {
+			return err
+		}
+
+		digest, err := store.PutJSON(&packages{Charts: charts, Terraform: tfs})
+		if err != nil {
+			return err
+		}
+		store.Index.Packages[installation.Repository.Id] = digest
+
+		for _, ci := range charts {
+			for _, crd := range ci.Version.Crds {
+				blobs[crd.Blob] = true
+			}
+		}
+		for _, ti := range tfs {
+			blobs[ti.Version.Package] = true
+			if ti.Terraform.Dependencies != nil {
+				versions[ti.Terraform.Dependencies.ProviderVsn] = true
+			}
+		}
+	}
+	utils.Success("captured %d %s\n", len(installations), utils.Pluralize("installation", "installations", len(installations)))
+
+	name := provider.ScaffoldName(prov)
+	for version := range versions {
+		scaffold, err := client.GetTfProviderScaffold(name, version)
+		if err != nil {
+			return err
+		}
+		digest, err := store.Put([]byte(scaffold))
+		if err != nil {
+			return err
+		}
+		store.Index.Scaffolds[scaffoldKey(name, version)] = digest
+	}
+	utils.Success("captured %d %s scaffold %s\n", len(versions), prov, utils.Pluralize("version", "versions", len(versions)))
+
+	if err := download(store, blobs); err != nil {
+		return err
+	}
+	utils.Success("captured %d crds and terraform modules\n", len(blobs))
+
+	return store.Flush()
+}
+
+func download(store *Store, blobs map[string]bool) error {
+	urls := make([]string, 0, len(blobs))
+	for url := range blobs {
+		if url != "" {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+
+	var mut sync.Mutex
+	var group errgroup.Group
+	group.SetLimit(wkspace.MaxConcurrentFetches)
+	for _, url := range urls {
+		url := url
+		group.Go(func() error {
+			contents, err := fetch(url)
+			if err != nil {
+				return err
+			}
+			digest, err := store.Put(contents)
+			if err != nil {
+				return err
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+			store.Index.Blobs[url] = digest
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
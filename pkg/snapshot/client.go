@@ -0,0 +1,266 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pluralsh/gqlclient"
+	"github.com/pluralsh/plural/pkg/api"
+)
+
+// ErrOffline is returned for every api call a snapshot can't answer
+var ErrOffline = errors.New("this requires the plural api, which isn't available with --snapshot")
+
+// Client is an api.Client serving installations, packages and provider scaffolds from a snapshot,
+// so builds are reproducible without access to the api.  Anything else fails with ErrOffline
+type Client struct {
+	store *Store
+}
+
+var _ api.Client = &Client{}
+
+func NewClient(store *Store) *Client {
+	return &Client{store: store}
+}
+
+func (c *Client) GetInstallations() ([]*api.Installation, error) {
+	installations := []*api.Installation{}
+	if err := c.store.GetJSON(c.store.Index.Installations, &installations); err != nil {
+		return nil, err
+	}
+	return installations, nil
+}
+
+func (c *Client) GetInstallation(name string) (*api.Installation, error) {
+	installations, err := c.GetInstallations()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range installations {
+		if inst.Repository.Name == name {
+			return inst, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Client) GetInstallationById(id string) (*api.Installation, error) {
+	installations, err := c.GetInstallations()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range installations {
+		if inst.Id == id {
+			return inst, nil
+		}
+	}
+	return nil, fmt.Errorf("installation %s is not in the snapshot", id)
+}
+
+func (c *Client) GetPackageInstallations(repoId string) ([]*api.ChartInstallation, []*api.TerraformInstallation, error) {
+	digest, ok := c.store.Index.Packages[repoId]
+	if !ok {
+		return nil, nil, fmt.Errorf("the packages of repository %s are not in the snapshot, run `plural snapshot` again", repoId)
+	}
+
+	pkgs := &packages{}
+	if err := c.store.GetJSON(digest, pkgs); err != nil {
+		return nil, nil, err
+	}
+	return pkgs.Charts, pkgs.Terraform, nil
+}
+
+func (c *Client) GetChartInstallations(repoId string) ([]*api.ChartInstallation, error) {
+	charts, _, err := c.GetPackageInstallations(repoId)
+	return charts, err
+}
+
+func (c *Client) GetTerraformInstallations(repoId string) ([]*api.TerraformInstallation, error) {
+	_, tfs, err := c.GetPackageInstallations(repoId)
+	return tfs, err
+}
+
+func (c *Client) GetTfProviderScaffold(name, version string) (string, error) {
+	digest, ok := c.store.Index.Scaffolds[scaffoldKey(name, version)]
+	if !ok {
+		return "", fmt.Errorf("the %s scaffold for provider version %q is not in the snapshot, run `plural snapshot` again", name, version)
+	}
+
+	contents, err := c.store.Get(digest)
+	return string(contents), err
+}
+
+// CreateEvent drops usage events, there's nowhere to send them
+func (c *Client) CreateEvent(_ *api.UserEventAttributes) error {
+	return nil
+}
+
+func (c *Client) ListArtifacts(_ string) ([]api.Artifact, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) CreateArtifact(_ string, _ api.ArtifactAttributes) (api.Artifact, error) {
+	return api.Artifact{}, ErrOffline
+}
+
+func (c *Client) Me() (*api.Me, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) LoginMethod(_ string) (*api.LoginMethod, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) PollLoginToken(_ string) (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) DeviceLogin() (*api.DeviceLogin, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) Login(_ string, _ string) (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) ImpersonateServiceAccount(_ string) (string, string, error) {
+	return "", "", ErrOffline
+}
+
+func (c *Client) CreateAccessToken() (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) GrabAccessToken() (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) ListKeys(_ []string) ([]*api.PublicKey, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) CreateKey(_ string, _ string) error {
+	return ErrOffline
+}
+
+func (c *Client) GetEabCredential(_ string, _ string) (*api.EabCredential, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) DeleteEabCredential(_ string, _ string) error {
+	return ErrOffline
+}
+
+func (c *Client) GetTfProviders() ([]string, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) GetRepository(_ string) (*api.Repository, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) CreateRepository(_ string, _ string, _ *gqlclient.RepositoryAttributes) error {
+	return ErrOffline
+}
+
+func (c *Client) AcquireLock(_ string) (*api.ApplyLock, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) ReleaseLock(_ string, _ string) (*api.ApplyLock, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) UnlockRepository(_ string) error {
+	return ErrOffline
+}
+
+func (c *Client) ListRepositories(_ string) ([]*api.Repository, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) Scaffolds(_ *api.ScaffoldInputs) ([]*api.ScaffoldFile, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) UpdateVersion(_ *api.VersionSpec, _ []string) error {
+	return ErrOffline
+}
+
+func (c *Client) GetCharts(_ string) ([]*api.Chart, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) GetVersions(_ string) ([]*api.Version, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) CreateCrd(_ string, _ string, _ string) error {
+	return ErrOffline
+}
+
+func (c *Client) CreateDomain(_ string) error {
+	return ErrOffline
+}
+
+func (c *Client) OIDCProvider(_ string, _ *api.OidcProviderAttributes) error {
+	return ErrOffline
+}
+
+func (c *Client) ResetInstallations() (int, error) {
+	return 0, ErrOffline
+}
+
+func (c *Client) CreateRecipe(_ string, _ gqlclient.RecipeAttributes) (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) GetRecipe(_ string, _ string) (*api.Recipe, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) ListRecipes(_ string, _ string) ([]*api.Recipe, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) InstallRecipe(_ string) error {
+	return ErrOffline
+}
+
+func (c *Client) GetShell() (api.CloudShell, error) {
+	return api.CloudShell{}, ErrOffline
+}
+
+func (c *Client) DeleteShell() error {
+	return ErrOffline
+}
+
+func (c *Client) GetTerraforma(_ string) ([]*api.Terraform, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) UploadTerraform(_ string, _ string) (api.Terraform, error) {
+	return api.Terraform{}, ErrOffline
+}
+
+func (c *Client) GetStack(name, _ string) (*api.Stack, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) CreateStack(_ gqlclient.StackAttributes) (string, error) {
+	return "", ErrOffline
+}
+
+func (c *Client) ListStacks(_ bool) ([]*api.Stack, error) {
+	return nil, ErrOffline
+}
+
+func (c *Client) UninstallChart(_ string) error {
+	return ErrOffline
+}
+
+func (c *Client) UninstallTerraform(_ string) error {
+	return ErrOffline
+}
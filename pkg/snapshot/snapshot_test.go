@@ -0,0 +1,77 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/snapshot"
+	"github.com/pluralsh/plural/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureAndServe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "contents of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	installations := []*api.Installation{{Id: "i1", Repository: &api.Repository{Id: "r1", Name: "airflow"}}}
+	charts := []*api.ChartInstallation{{
+		Chart:   &api.Chart{Name: "airflow"},
+		Version: &api.Version{Id: "v1", Crds: []api.Crd{{Name: "crd.yaml", Blob: server.URL + "/crd.yaml"}}},
+	}}
+	tfs := []*api.TerraformInstallation{{
+		Terraform: &api.Terraform{Name: "aws", Dependencies: &api.Dependencies{ProviderVsn: "0.1.0"}},
+		Version:   &api.Version{Id: "v2", Package: server.URL + "/aws.tgz"},
+	}}
+
+	client := mocks.NewClient(t)
+	client.On("GetInstallations").Return(installations, nil)
+	client.On("GetPackageInstallations", "r1").Return(charts, tfs, nil)
+	client.On("GetTfProviderScaffold", "AWS", "0.1.0").Return("provider scaffold", nil)
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := snapshot.Create(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, snapshot.Capture(client, store, "aws"))
+
+	store, err = snapshot.Open(dir)
+	assert.NoError(t, err)
+	offline := snapshot.NewClient(store)
+
+	inst, err := offline.GetInstallation("airflow")
+	assert.NoError(t, err)
+	assert.Equal(t, "i1", inst.Id)
+
+	ci, ti, err := offline.GetPackageInstallations("r1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", ci[0].Version.Id)
+	assert.Equal(t, "0.1.0", ti[0].Terraform.Dependencies.ProviderVsn)
+
+	scaffold, err := offline.GetTfProviderScaffold("AWS", "0.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "provider scaffold", scaffold)
+
+	_, err = offline.Me()
+	assert.ErrorIs(t, err, snapshot.ErrOffline)
+
+	// downloads come from the snapshot, even once the server is gone
+	server.Close()
+	httpClient := &http.Client{Transport: snapshot.NewTransport(store)}
+	resp, err := httpClient.Get(server.URL + "/aws.tgz")
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "contents of /aws.tgz", string(body))
+
+	_, err = httpClient.Get(server.URL + "/unknown")
+	assert.ErrorIs(t, err, snapshot.ErrOffline)
+}
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+const indexFile = "index.json"
+
+// Index maps everything captured in a snapshot to the digest of its contents in the store
+type Index struct {
+	CapturedAt    time.Time         `json:"capturedAt"`
+	Installations string            `json:"installations"`
+	Packages      map[string]string `json:"packages"`
+	Scaffolds     map[string]string `json:"scaffolds"`
+	Blobs         map[string]string `json:"blobs"`
+}
+
+// Store is a content-addressed directory of api responses and downloads, with an index on top
+type Store struct {
+	Dir   string
+	Index *Index
+}
+
+// DefaultDir is where snapshots of the workspace at root are kept unless told otherwise
+func DefaultDir(root string) string {
+	return pathing.SanitizeFilepath(filepath.Join(root, ".plural", "snapshot"))
+}
+
+// Open reads the snapshot in dir
+func Open(dir string) (*Store, error) {
+	contents, err := ioutil.ReadFile(pathing.SanitizeFilepath(filepath.Join(dir, indexFile)))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no snapshot found in %s, run `plural snapshot` while the plural api is reachable", dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := &Index{}
+	if err := json.Unmarshal(contents, index); err != nil {
+		return nil, fmt.Errorf("corrupt snapshot index in %s: %w", dir, err)
+	}
+	return &Store{Dir: dir, Index: index}, nil
+}
+
+// Create starts an empty snapshot in dir.  Objects of any previous snapshot there are kept, since
+// they're addressed by content, but its index is only replaced once the new one is flushed
+func Create(dir string) (*Store, error) {
+	if err := os.MkdirAll(pathing.SanitizeFilepath(filepath.Join(dir, "objects")), 0755); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		Dir: dir,
+		Index: &Index{
+			CapturedAt: time.Now(),
+			Packages:   make(map[string]string),
+			Scaffolds:  make(map[string]string),
+			Blobs:      make(map[string]string),
+		},
+	}, nil
+}
+
+func (s *Store) Flush() error {
+	contents, err := json.MarshalIndent(s.Index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(pathing.SanitizeFilepath(filepath.Join(s.Dir, indexFile)), contents, 0644)
+}
+
+// Put stores contents under their sha256 digest, which it returns
+func (s *Store) Put(contents []byte) (string, error) {
+	sum := sha256.Sum256(contents)
+	digest := hex.EncodeToString(sum[:])
+	path := s.objectPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+
+	// write then rename, so a crash can't leave a truncated object behind its digest
+	tmp, err := ioutil.TempFile(filepath.Dir(path), digest)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	return digest, os.Rename(tmp.Name(), path)
+}
+
+// Get reads the object with the given digest, verifying it hasn't been tampered with
+func (s *Store) Get(digest string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(s.objectPath(digest))
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(contents)
+	if hex.EncodeToString(sum[:]) != digest {
+		return nil, fmt.Errorf("snapshot object %s is corrupt", digest)
+	}
+	return contents, nil
+}
+
+func (s *Store) PutJSON(val interface{}) (string, error) {
+	contents, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return s.Put(contents)
+}
+
+func (s *Store) GetJSON(digest string, val interface{}) error {
+	contents, err := s.Get(digest)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, val)
+}
+
+func (s *Store) objectPath(digest string) string {
+	return pathing.SanitizeFilepath(filepath.Join(s.Dir, "objects", digest))
+}
@@ -0,0 +1,43 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Transport serves the crds and terraform modules captured in a snapshot in place of downloading them,
+// refusing any other request
+type Transport struct {
+	store *Store
+}
+
+func NewTransport(store *Store) *Transport {
+	return &Transport{store: store}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := req.URL.String()
+	digest, ok := t.store.Index.Blobs[url]
+	if !ok || req.Method != http.MethodGet {
+		return nil, fmt.Errorf("%s %s is not in the snapshot: %w", req.Method, url, ErrOffline)
+	}
+
+	contents, err := t.store.Get(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(contents)),
+		ContentLength: int64(len(contents)),
+		Request:       req,
+	}, nil
+}
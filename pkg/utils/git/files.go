@@ -0,0 +1,63 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/errors"
+)
+
+// CommitPaths commits and pushes only the given paths, leaving any other local changes alone.  If
+// the push is rejected the commit is undone, and the paths are restored to their previous state
+func CommitPaths(root, msg string, paths ...string) error {
+	args := append([]string{"add", "-A", "--"}, paths...)
+	if res, err := git(root, args...); err != nil {
+		return errors.ErrorWrap(fmt.Errorf(res), "`git add` failed")
+	}
+
+	args = append([]string{"commit", "-m", msg, "--"}, paths...)
+	if res, err := git(root, args...); err != nil {
+		return errors.ErrorWrap(fmt.Errorf(res), "failed to commit changes")
+	}
+
+	branch, err := CurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	if res, err := git(root, "push", "origin", branch); err != nil {
+		_, _ = git(root, "reset", "-q", "--soft", "HEAD~1")
+		args = append([]string{"checkout", "HEAD", "--"}, paths...)
+		if _, err := git(root, args...); err != nil {
+			args = append([]string{"rm", "-q", "-f", "--ignore-unmatch", "--"}, paths...)
+			_, _ = git(root, args...)
+		}
+		return errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("`git push origin %s` failed", branch))
+	}
+
+	return nil
+}
+
+// ListFiles lists the files under root that git tracks or would track, skipping anything gitignored
+func ListFiles(root string) ([]string, error) {
+	res, err := git(root, "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, errors.ErrorWrap(fmt.Errorf(res), "`git ls-files` failed")
+	}
+
+	files := make([]string, 0)
+	for _, file := range strings.Split(res, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// CheckoutBranch creates a branch from HEAD and switches to it
+func CheckoutBranch(root, branch string) error {
+	if res, err := git(root, "checkout", "-b", branch); err != nil {
+		return errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("`git checkout -b %s` failed", branch))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils/errors"
+)
+
+// RefFile reads path as of the tip of ref on origin, returning the commit it read it from, or an empty
+// commit and nil contents if origin doesn't have the ref
+func RefFile(root, ref, path string) (string, []byte, error) {
+	res, err := git(root, "ls-remote", "origin", ref)
+	if err != nil {
+		return "", nil, errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("`git ls-remote origin %s` failed", ref))
+	}
+	fields := strings.Fields(res)
+	if len(fields) == 0 {
+		return "", nil, nil
+	}
+
+	commit := fields[0]
+	if res, err := git(root, "fetch", "-q", "origin", ref); err != nil {
+		return "", nil, errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("`git fetch origin %s` failed", ref))
+	}
+
+	res, err = git(root, "show", fmt.Sprintf("%s:%s", commit, path))
+	if err != nil {
+		return commit, nil, nil
+	}
+	return commit, []byte(res), nil
+}
+
+// PushRefFile pushes a commit holding only path, with contents, to ref on origin, without touching the
+// working tree or any branch.  The push only succeeds if origin's ref is still at expected, or doesn't
+// exist if expected is empty, so concurrent pushes can't both win.  It returns the commit pushed
+func PushRefFile(root, ref, expected, path, msg string, contents []byte) (string, error) {
+	blob, err := gitInput(root, contents, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", errors.ErrorWrap(err, "failed to write the git blob")
+	}
+
+	tree, err := gitInput(root, []byte(fmt.Sprintf("100644 blob %s\t%s\n", blob, path)), "mktree")
+	if err != nil {
+		return "", errors.ErrorWrap(err, "failed to write the git tree")
+	}
+
+	commit, err := git(root, "commit-tree", tree, "-m", msg)
+	if err != nil {
+		return "", errors.ErrorWrap(fmt.Errorf(commit), "failed to commit to "+ref)
+	}
+
+	if res, err := git(root, "push", lease(ref, expected), "origin", fmt.Sprintf("%s:%s", commit, ref)); err != nil {
+		return "", errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("`git push origin %s` failed", ref))
+	}
+	return commit, nil
+}
+
+// DeleteRef deletes ref on origin, as long as it's still at expected
+func DeleteRef(root, ref, expected string) error {
+	if res, err := git(root, "push", lease(ref, expected), "origin", ":"+ref); err != nil {
+		return errors.ErrorWrap(fmt.Errorf(res), fmt.Sprintf("failed to delete %s from origin", ref))
+	}
+	return nil
+}
+
+func lease(ref, expected string) string {
+	return fmt.Sprintf("--force-with-lease=%s:%s", ref, expected)
+}
+
+func gitInput(root string, input []byte, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = root
+	cmd.Stdin = bytes.NewReader(input)
+	res, err := execute(cmd)
+	return strings.TrimSpace(res), err
+}
@@ -8,12 +8,43 @@ import (
 	gogit "github.com/go-git/go-git/v5"
 )
 
+// scopedRoot, when set, is a directory within the repo that Root returns in place of its toplevel
+var scopedRoot string
+
+// ScopeRoot makes Root return dir, a directory within the repo, so everything working relative to the
+// root of the installation repo works within it instead, like the workspace of an environment
+func ScopeRoot(dir string) {
+	scopedRoot = dir
+}
+
+// Root returns the root of the installation repo, which is the repo's toplevel unless it was scoped
+// to a directory within it with ScopeRoot
 func Root() (string, error) {
+	if scopedRoot != "" {
+		return scopedRoot, nil
+	}
+	return Toplevel()
+}
+
+// Toplevel returns the toplevel of the git repo, regardless of any ScopeRoot
+func Toplevel() (string, error) {
 	return gitRaw("rev-parse", "--show-toplevel")
 }
 
+// GitDir returns the absolute path of the git directory of the repo containing dir, where plural keeps
+// state that must never be committed
+func GitDir(dir string) (string, error) {
+	return git(dir, "rev-parse", "--absolute-git-dir")
+}
+
+// Prefix returns the path of dir relative to the toplevel of its repo, with a trailing slash unless
+// it's the toplevel itself
+func Prefix(dir string) (string, error) {
+	return git(dir, "rev-parse", "--show-prefix")
+}
+
 func Repo() (*gogit.Repository, error) {
-	root, err := Root()
+	root, err := Toplevel()
 	if err != nil {
 		return nil, err
 	}
@@ -68,3 +99,7 @@ func HasUpstreamChanges() (bool, error) {
 func Init() (string, error) {
 	return gitRaw("init")
 }
+
+func HeadCommit() (string, error) {
+	return gitRaw("rev-parse", "HEAD")
+}
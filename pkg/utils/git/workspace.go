@@ -1,22 +1,47 @@
 package git
 
 import (
+	"path/filepath"
 	"strings"
 )
 
+// Modified lists the paths with uncommitted changes, relative to Root
 func Modified() ([]string, error) {
 	res, err := gitRaw("status", "--porcelain")
 	if err != nil {
 		return nil, err
 	}
 
+	prefix, err := scopePrefix()
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]string, 0)
 	for _, line := range strings.Split(res, "\n") {
 		cols := strings.Fields(strings.TrimSpace(line))
-		if len(cols) > 1 {
-			result = append(result, cols[1])
+		if len(cols) > 1 && strings.HasPrefix(cols[1], prefix) {
+			result = append(result, strings.TrimPrefix(cols[1], prefix))
 		}
 	}
 
 	return result, nil
 }
+
+// scopePrefix is the path of Root within the repo as git reports paths, or empty if it isn't scoped
+func scopePrefix() (string, error) {
+	if scopedRoot == "" {
+		return "", nil
+	}
+
+	toplevel, err := Toplevel()
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(toplevel, scopedRoot)
+	if err != nil || rel == "." {
+		return "", err
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
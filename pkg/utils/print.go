@@ -3,6 +3,7 @@ package utils
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"syscall"
@@ -67,6 +68,14 @@ func Highlight(line string, args ...interface{}) {
 	color.New(color.Bold).Printf(line, args...)
 }
 
+func SuccessTo(w io.Writer, line string, args ...interface{}) {
+	color.New(color.FgGreen, color.Bold).Fprintf(w, line, args...)
+}
+
+func HighlightTo(w io.Writer, line string, args ...interface{}) {
+	color.New(color.Bold).Fprintf(w, line, args...)
+}
+
 func Note(line string, args ...interface{}) {
 	Warn("**NOTE** :: ")
 	Highlight(line, args...)
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	toposort "github.com/philopon/go-toposort"
 )
@@ -9,14 +11,20 @@ import (
 type SafeGraph struct {
 	Graph   *toposort.Graph
 	Present map[string]bool
+	nodes   []string
+	edges   map[string][]string
 }
 
 func Graph(size int) *SafeGraph {
-	return &SafeGraph{Graph: toposort.NewGraph(size), Present: make(map[string]bool)}
+	return &SafeGraph{Graph: toposort.NewGraph(size), Present: make(map[string]bool), edges: make(map[string][]string)}
 }
 
 func (g *SafeGraph) AddNode(name string) bool {
-	return g.Graph.AddNode(name)
+	if !g.Graph.AddNode(name) {
+		return false
+	}
+	g.nodes = append(g.nodes, name)
+	return true
 }
 
 func (g *SafeGraph) AddEdge(in, out string) bool {
@@ -25,9 +33,68 @@ func (g *SafeGraph) AddEdge(in, out string) bool {
 		return false
 	}
 	g.Present[key] = true
+	g.edges[in] = append(g.edges[in], out)
 	return g.Graph.AddEdge(in, out)
 }
 
 func (g *SafeGraph) Topsort() ([]string, bool) {
 	return g.Graph.Toposort()
 }
+
+// Cycle finds a cycle in the graph, returned as the path of nodes around it ending back at the first,
+// or nil if there is none.  Nodes are walked by name, so the same cycle is reported however the graph
+// was built
+func (g *SafeGraph) Cycle() []string {
+	nodes := append([]string{}, g.nodes...)
+	sort.Strings(nodes)
+	return FindCycle(nodes, g.edges)
+}
+
+// FindCycle walks the edges from each node in order, returning the first cycle it finds as a path
+// ending where it started, or nil if the graph is acyclic
+func FindCycle(nodes []string, edges map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	path := make([]string, 0)
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range edges[node] {
+			switch state[next] {
+			case visiting:
+				for i, n := range path {
+					if n == next {
+						return append(append([]string{}, path[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// FormatCycle renders a cycle as a -> b -> a
+func FormatCycle(cycle []string) string {
+	return strings.Join(cycle, " -> ")
+}
@@ -1,37 +1,27 @@
 package wkspace
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
-	"strings"
 	"time"
 
 	"github.com/pluralsh/plural/pkg/kubernetes"
 
 	"github.com/pluralsh/plural/pkg/executor"
-	"github.com/pluralsh/plural/pkg/utils"
 	"github.com/pluralsh/plural/pkg/utils/git"
 	"github.com/pluralsh/plural/pkg/utils/pathing"
 )
 
-type checker func(s string) bool
-
-var alwaysErr checker = func(s string) bool { return false }
-
-func (c checker) execSuppressed(command string, args ...string) (err error) {
-	for retry := 2; retry >= 0; retry-- {
-		utils.Highlight("%s %s ~> ", command, strings.Join(args, " "))
-		cmd, out := executor.SuppressedCommand(command, args...)
-		err = executor.RunCommand(cmd, out)
-		if err == nil || c(out.Format()) {
-			break
-		}
-		fmt.Printf("retrying command, number of retries remaining: %d\n", retry)
-	}
+var (
+	alwaysRetry    = &executor.RetryPolicy{MaxAttempts: 3}
+	ignoreNotFound = &executor.RetryPolicy{MaxAttempts: 3, Fatal: []string{"release.*not found"}}
+)
 
-	return
+func execSuppressed(policy *executor.RetryPolicy, command string, args ...string) error {
+	step := executor.Step{Name: command, Command: command, Args: args, Retry: policy}
+	return step.Attempt(context.Background(), "")
 }
 
 func (w *Workspace) DestroyHelm() error {
@@ -43,14 +33,12 @@ func (w *Workspace) DestroyHelm() error {
 	name := w.Installation.Repository.Name
 
 	ns := w.Config.Namespace(name)
-	if err := alwaysErr.execSuppressed("helm", "get", "values", name, "-n", ns); err != nil {
+	if err := execSuppressed(alwaysRetry, "helm", "get", "values", name, "-n", ns); err != nil {
 		fmt.Println("Helm already uninstalled, continuing...")
 		return nil
 	}
 
-	r := regexp.MustCompile("release.*not found")
-	var ignoreNotFound checker = func(s string) bool { return r.MatchString(s) }
-	return ignoreNotFound.execSuppressed("helm", "del", name, "-n", ns)
+	return execSuppressed(ignoreNotFound, "helm", "del", name, "-n", ns)
 }
 
 func (w *Workspace) Bounce() error {
@@ -110,9 +98,9 @@ func (w *Workspace) DestroyTerraform() error {
 	if err := os.Chdir(path); err != nil {
 		return err
 	}
-	if err := alwaysErr.execSuppressed("terraform", "init", "-upgrade"); err != nil {
+	if err := execSuppressed(alwaysRetry, "terraform", "init", "-upgrade"); err != nil {
 		return err
 	}
 
-	return alwaysErr.execSuppressed("terraform", "destroy", "-auto-approve")
+	return execSuppressed(alwaysRetry, "terraform", "destroy", "-auto-approve")
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/pluralsh/plural/pkg/crypto"
 	"github.com/pluralsh/plural/pkg/diff"
 	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/history"
 	"github.com/pluralsh/plural/pkg/manifest"
 	"github.com/pluralsh/plural/pkg/provider"
 	"github.com/pluralsh/plural/pkg/utils"
@@ -187,7 +188,12 @@ func (wk *Workspace) buildExecution(repoRoot string) error {
 
 	exec, _ := executor.GetExecution(pathing.SanitizeFilepath(wkspaceRoot), "deploy")
 
-	return executor.DefaultExecution(name, exec).Flush(repoRoot)
+	execution, err := executor.DefaultExecution(name, exec)
+	if err != nil {
+		return err
+	}
+
+	return execution.Flush(repoRoot)
 }
 
 func (wk *Workspace) buildDiff(repoRoot string) error {
@@ -196,7 +202,12 @@ func (wk *Workspace) buildDiff(repoRoot string) error {
 
 	d, _ := diff.GetDiff(pathing.SanitizeFilepath(wkspaceRoot), "diff")
 
-	return diff.DefaultDiff(name, d).Flush(repoRoot)
+	defaultDiff, err := diff.DefaultDiff(name, d)
+	if err != nil {
+		return err
+	}
+
+	return defaultDiff.Flush(repoRoot)
 }
 
 func DiffedRepos() ([]string, error) {
@@ -213,6 +224,10 @@ func DiffedRepos() ([]string, error) {
 			continue
 		}
 
+		if history.Journaled(file) {
+			continue
+		}
+
 		maybeRepo := parts[0]
 		if utils.Exists(manifestPath(maybeRepo)) && file != manifestPath(maybeRepo) {
 			repos[maybeRepo] = true
@@ -0,0 +1,223 @@
+package wkspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+)
+
+// DependencyEdge is a repo's dependency on another installed repo, typed by whether it's a helm or
+// terraform dependency
+type DependencyEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional"`
+}
+
+// DependencyGraph is the graph of dependencies between installed repos, along with the order they
+// deploy in, or the cycle preventing them from being ordered
+type DependencyGraph struct {
+	Repos []string          `json:"repos"`
+	Edges []*DependencyEdge `json:"edges"`
+	Order []string          `json:"order,omitempty"`
+	Cycle []string          `json:"cycle,omitempty"`
+}
+
+// BuildGraph builds the dependency graph of the installed repos from their chart and terraform
+// packages.  Dependencies on repos that aren't installed are left out, as TopSort ignores them too
+func BuildGraph(client api.Client, installations []*api.Installation) (*DependencyGraph, error) {
+	cache := NewPackageCache(client)
+	if err := cache.Prefetch(installations); err != nil {
+		return nil, err
+	}
+
+	installed := make(map[string]bool)
+	for _, installation := range installations {
+		installed[installation.Repository.Name] = true
+	}
+
+	graph := &DependencyGraph{Repos: []string{}, Edges: []*DependencyEdge{}}
+	edges := make(map[string]*DependencyEdge)
+	for _, installation := range installations {
+		repo := installation.Repository.Name
+		graph.Repos = append(graph.Repos, repo)
+
+		charts, tfs, err := cache.GetPackageInstallations(installation.Repository.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		deps := make([]*api.Dependency, 0)
+		for _, chart := range charts {
+			deps = append(deps, chart.Chart.Dependencies.Dependencies...)
+		}
+		for _, tf := range tfs {
+			deps = append(deps, tf.Terraform.Dependencies.Dependencies...)
+		}
+
+		for _, dep := range deps {
+			if dep.Repo == repo || !installed[dep.Repo] {
+				continue
+			}
+
+			typ := strings.ToLower(dep.Type)
+			key := fmt.Sprintf("%s:%s:%s", repo, dep.Repo, typ)
+			if edge, ok := edges[key]; ok {
+				// a required dependency of the same type outweighs an optional one
+				edge.Optional = edge.Optional && dep.Optional
+				continue
+			}
+
+			edge := &DependencyEdge{From: repo, To: dep.Repo, Type: typ, Optional: dep.Optional}
+			edges[key] = edge
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+
+	sort.Strings(graph.Repos)
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+	graph.sort()
+	return graph, nil
+}
+
+func (g *DependencyGraph) adjacency() map[string][]string {
+	adj := make(map[string][]string)
+	for _, edge := range g.Edges {
+		adj[edge.From] = append(adj[edge.From], edge.To)
+	}
+	return adj
+}
+
+// sort fills in the deploy order, or the cycle if there isn't one
+func (g *DependencyGraph) sort() {
+	g.Order, g.Cycle = nil, nil
+	if cycle := utils.FindCycle(g.Repos, g.adjacency()); cycle != nil {
+		g.Cycle = cycle
+		return
+	}
+
+	adj := g.adjacency()
+	layers, _ := layerer(g.Repos, func(repo string) ([]*manifest.Dependency, error) {
+		deps := make([]*manifest.Dependency, 0)
+		for _, dep := range adj[repo] {
+			deps = append(deps, &manifest.Dependency{Repo: dep})
+		}
+		return deps, nil
+	})
+	for _, layer := range layers {
+		g.Order = append(g.Order, layer...)
+	}
+}
+
+// Subgraph narrows the graph to a repo and everything it transitively depends on
+func (g *DependencyGraph) Subgraph(repo string) (*DependencyGraph, error) {
+	adj := g.adjacency()
+	keep := map[string]bool{}
+	var visit func(string)
+	visit = func(r string) {
+		if keep[r] {
+			return
+		}
+		keep[r] = true
+		for _, dep := range adj[r] {
+			visit(dep)
+		}
+	}
+
+	found := false
+	for _, r := range g.Repos {
+		found = found || r == repo
+	}
+	if !found {
+		return nil, fmt.Errorf("%s is not installed", repo)
+	}
+	visit(repo)
+
+	sub := &DependencyGraph{Repos: []string{}, Edges: []*DependencyEdge{}}
+	for _, r := range g.Repos {
+		if keep[r] {
+			sub.Repos = append(sub.Repos, r)
+		}
+	}
+	for _, edge := range g.Edges {
+		if keep[edge.From] && keep[edge.To] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+	sub.sort()
+	return sub, nil
+}
+
+// inCycle reports whether an edge is one of the edges around the graph's cycle
+func (g *DependencyGraph) inCycle(edge *DependencyEdge) bool {
+	for i := 0; i < len(g.Cycle)-1; i++ {
+		if g.Cycle[i] == edge.From && g.Cycle[i+1] == edge.To {
+			return true
+		}
+	}
+	return false
+}
+
+// Dot renders the graph in graphviz's dot language, with edges pointing from a repo to its
+// dependencies, optional ones dashed and any cycle in red
+func (g *DependencyGraph) Dot() string {
+	var b strings.Builder
+	b.WriteString("digraph plural {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, repo := range g.Repos {
+		fmt.Fprintf(&b, "  %q;\n", repo)
+	}
+	for _, edge := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", edge.Type)}
+		if edge.Optional {
+			attrs = append(attrs, "style=dashed")
+		}
+		if g.inCycle(edge) {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", edge.From, edge.To, strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a mermaid flowchart, with optional edges dotted and any cycle in red
+func (g *DependencyGraph) Mermaid() string {
+	ids := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, repo := range g.Repos {
+		ids[repo] = fmt.Sprintf("r%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[repo], repo)
+	}
+
+	cycle := []string{}
+	for i, edge := range g.Edges {
+		arrow := "-->"
+		if edge.Optional {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s|%s| %s\n", ids[edge.From], arrow, edge.Type, ids[edge.To])
+		if g.inCycle(edge) {
+			cycle = append(cycle, fmt.Sprint(i))
+		}
+	}
+	if len(cycle) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:red\n", strings.Join(cycle, ","))
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package wkspace_test
+
+import (
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/test/mocks"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func chart(deps ...*api.Dependency) []*api.ChartInstallation {
+	return []*api.ChartInstallation{{Chart: &api.Chart{Dependencies: &api.Dependencies{Dependencies: deps}}}}
+}
+
+func terraform(deps ...*api.Dependency) []*api.TerraformInstallation {
+	return []*api.TerraformInstallation{{Terraform: &api.Terraform{Dependencies: &api.Dependencies{Dependencies: deps}}}}
+}
+
+func TestBuildGraph(t *testing.T) {
+	installations := []*api.Installation{
+		{Repository: &api.Repository{Id: "1", Name: "bootstrap"}},
+		{Repository: &api.Repository{Id: "2", Name: "postgres"}},
+		{Repository: &api.Repository{Id: "3", Name: "airflow"}},
+	}
+
+	tests := []struct {
+		name      string
+		bootstrap []*api.Dependency
+		order     []string
+		sub       []string
+		cycle     []string
+		dot       string
+		mermaid   string
+	}{
+		{
+			name:  `test an acyclic graph is ordered`,
+			order: []string{"bootstrap", "postgres", "airflow"},
+			sub:   []string{"bootstrap", "postgres"},
+			dot: `digraph plural {
+  rankdir=LR;
+  "airflow";
+  "bootstrap";
+  "postgres";
+  "airflow" -> "bootstrap" [label="helm"];
+  "airflow" -> "postgres" [label="helm"];
+  "airflow" -> "postgres" [label="terraform", style=dashed];
+  "postgres" -> "bootstrap" [label="terraform"];
+}
+`,
+			mermaid: `flowchart LR
+  r0["airflow"]
+  r1["bootstrap"]
+  r2["postgres"]
+  r0 -->|helm| r1
+  r0 -->|helm| r2
+  r0 -.->|terraform| r2
+  r2 -->|terraform| r1
+`,
+		},
+		{
+			name:      `test a cycle is reported with its path`,
+			bootstrap: []*api.Dependency{{Type: "HELM", Repo: "airflow"}},
+			cycle:     []string{"airflow", "bootstrap", "airflow"},
+			sub:       []string{"airflow", "bootstrap", "postgres"},
+			mermaid: `flowchart LR
+  r0["airflow"]
+  r1["bootstrap"]
+  r2["postgres"]
+  r0 -->|helm| r1
+  r0 -->|helm| r2
+  r0 -.->|terraform| r2
+  r1 -->|helm| r0
+  r2 -->|terraform| r1
+  linkStyle 0,3 stroke:red
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := mocks.NewClient(t)
+			client.On("GetPackageInstallations", "1").Return(chart(test.bootstrap...), []*api.TerraformInstallation{}, nil)
+			client.On("GetPackageInstallations", "2").Return(chart(), terraform(&api.Dependency{Type: "TERRAFORM", Repo: "bootstrap"}), nil)
+			client.On("GetPackageInstallations", "3").Return(
+				chart(&api.Dependency{Type: "HELM", Repo: "bootstrap"}, &api.Dependency{Type: "HELM", Repo: "postgres"}, &api.Dependency{Type: "HELM", Repo: "grafana"}),
+				terraform(&api.Dependency{Type: "TERRAFORM", Repo: "postgres", Optional: true}),
+				nil,
+			)
+
+			graph, err := wkspace.BuildGraph(client, installations)
+			assert.NoError(t, err)
+			assert.Equal(t, test.order, graph.Order)
+			assert.Equal(t, test.cycle, graph.Cycle)
+			if test.dot != "" {
+				assert.Equal(t, test.dot, graph.Dot())
+			}
+			assert.Equal(t, test.mermaid, graph.Mermaid())
+
+			sub, err := graph.Subgraph("postgres")
+			assert.NoError(t, err)
+			assert.Equal(t, test.sub, sub.Repos)
+			assert.Equal(t, test.cycle, sub.Cycle)
+
+			_, err = wkspace.TopSort(client, installations)
+			if test.cycle != nil {
+				assert.EqualError(t, err, "Cycle detected in dependency graph, repos depend on each other in the cycle airflow -> bootstrap -> airflow")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
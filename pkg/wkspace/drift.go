@@ -0,0 +1,200 @@
+package wkspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/executor"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+type DriftStatus string
+
+const (
+	DriftInSync  DriftStatus = "in sync"
+	DriftDrifted DriftStatus = "drifted"
+	DriftError   DriftStatus = "error"
+)
+
+var (
+	terraformPlan = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+	helmResource  = regexp.MustCompile(`(?m)^\S.*, .* has (changed|been added|been removed):\s*$`)
+)
+
+// DriftResult compares one tool's desired state for a repo against what is actually running
+type DriftResult struct {
+	Tool    string      `json:"tool"`
+	Status  DriftStatus `json:"status"`
+	Added   int         `json:"added"`
+	Changed int         `json:"changed"`
+	Removed int         `json:"removed"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// RepoDrift aggregates the drift results of every tool used by a repo
+type RepoDrift struct {
+	Repo    string         `json:"repo"`
+	Status  DriftStatus    `json:"status"`
+	Results []*DriftResult `json:"results"`
+}
+
+func NewRepoDrift(repo string, results ...*DriftResult) *RepoDrift {
+	drift := &RepoDrift{Repo: repo, Status: DriftInSync, Results: []*DriftResult{}}
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+
+		drift.Results = append(drift.Results, res)
+		switch {
+		case res.Status == DriftError:
+			drift.Status = DriftError
+		case res.Status == DriftDrifted && drift.Status != DriftError:
+			drift.Status = DriftDrifted
+		}
+	}
+	return drift
+}
+
+func (d *RepoDrift) Result(tool string) *DriftResult {
+	for _, res := range d.Results {
+		if res.Tool == tool {
+			return res
+		}
+	}
+	return nil
+}
+
+func (r *DriftResult) String() string {
+	switch r.Status {
+	case DriftDrifted:
+		return fmt.Sprintf("drifted (+%d ~%d -%d)", r.Added, r.Changed, r.Removed)
+	default:
+		return string(r.Status)
+	}
+}
+
+// TerraformDrift runs `terraform plan -detailed-exitcode` for the repo under root, returning nil if
+// the repo has no terraform
+func (m *MinimalWorkspace) TerraformDrift(ctx context.Context, root string) *DriftResult {
+	dir := pathing.SanitizeFilepath(filepath.Join(root, m.Name, "terraform"))
+	if !utils.Exists(dir) {
+		return nil
+	}
+
+	result := &DriftResult{Tool: "terraform"}
+	if !utils.Exists(pathing.SanitizeFilepath(filepath.Join(dir, ".terraform"))) {
+		if out, _, err := detailedExitCode(ctx, dir, "terraform", "init", "-input=false", "-no-color"); err != nil {
+			return result.failed(out, err)
+		}
+	}
+
+	out, code, err := detailedExitCode(ctx, dir, "terraform", "plan", "-detailed-exitcode", "-input=false", "-lock=false", "-no-color")
+	if err != nil {
+		return result.failed(out, err)
+	}
+
+	result.Status = DriftInSync
+	if code == 2 {
+		result.Status = DriftDrifted
+		result.Added, result.Changed, result.Removed = CountTerraformChanges(out)
+	}
+	return result
+}
+
+// HelmDrift runs `helm diff upgrade --detailed-exitcode` for the repo's chart under root, returning nil
+// if the repo has no chart.  Secrets are suppressed, only the counts of changed resources are kept
+func (m *MinimalWorkspace) HelmDrift(ctx context.Context, root string) *DriftResult {
+	path := pathing.SanitizeFilepath(filepath.Join(root, m.Name, "helm", m.Name))
+	if !utils.Exists(path) {
+		return nil
+	}
+
+	result := &DriftResult{Tool: "helm"}
+	backup, err := templateVals(m.Name, path)
+	if err == nil {
+		defer func(oldpath, newpath string) {
+			_ = os.Rename(oldpath, newpath)
+		}(backup, pathing.SanitizeFilepath(filepath.Join(path, "values.yaml")))
+	}
+
+	namespace := m.Config.Namespace(m.Name)
+	out, code, err := detailedExitCode(ctx, path, "helm", "diff", "upgrade", "--install", "--detailed-exitcode", "--reset-values",
+		"--suppress-secrets", "--no-color", "--namespace", namespace, m.Name, path)
+	if err != nil {
+		return result.failed(out, err)
+	}
+
+	result.Status = DriftInSync
+	if code == 2 {
+		result.Status = DriftDrifted
+		result.Added, result.Changed, result.Removed = CountHelmChanges(out)
+	}
+	return result
+}
+
+func (r *DriftResult) failed(out string, err error) *DriftResult {
+	r.Status = DriftError
+	r.Error = err.Error()
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(out) > 0 {
+		r.Error = fmt.Sprintf("%s: %s", err, lines[len(lines)-1])
+	}
+	return r
+}
+
+// CountTerraformChanges reads the resources to add, change and destroy from a plan's summary line
+func CountTerraformChanges(out string) (added, changed, removed int) {
+	match := terraformPlan.FindStringSubmatch(out)
+	if match == nil {
+		return
+	}
+
+	added, _ = strconv.Atoi(match[1])
+	changed, _ = strconv.Atoi(match[2])
+	removed, _ = strconv.Atoi(match[3])
+	return
+}
+
+// CountHelmChanges counts the resource headers in helm diff output, eg `default, web, Deployment (apps) has changed:`
+func CountHelmChanges(out string) (added, changed, removed int) {
+	for _, match := range helmResource.FindAllStringSubmatch(out, -1) {
+		switch match[1] {
+		case "been added":
+			added++
+		case "changed":
+			changed++
+		case "been removed":
+			removed++
+		}
+	}
+	return
+}
+
+// detailedExitCode runs a command whose exit code 2 signals changes rather than failure.  Cancelling
+// ctx interrupts it like a deploy step, so terraform can release its state lock before exiting
+func detailedExitCode(ctx context.Context, dir, command string, args ...string) (string, int, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := executor.RunContext(ctx, cmd)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 2 {
+		return out.String(), 2, nil
+	}
+	if err != nil {
+		return out.String(), -1, fmt.Errorf("%s %s failed: %w", command, args[0], err)
+	}
+	return out.String(), 0, nil
+}
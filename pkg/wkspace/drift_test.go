@@ -0,0 +1,89 @@
+package wkspace_test
+
+import (
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		output   string
+		expected []int
+	}{
+		{
+			name:     `test terraform plan summary`,
+			tool:     "terraform",
+			output:   "  # aws_s3_bucket.airflow will be updated in-place\n\nPlan: 1 to add, 2 to change, 3 to destroy.\n",
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     `test terraform plan without resource changes`,
+			tool:     "terraform",
+			output:   "Changes to Outputs:\n  + bucket = \"airflow\"\n",
+			expected: []int{0, 0, 0},
+		},
+		{
+			name: `test helm diff resource headers`,
+			tool: "helm",
+			output: `airflow, airflow-web, Deployment (apps) has changed:
+-         replicas: 1
++         replicas: 2
+airflow, airflow-worker, Deployment (apps) has changed:
+airflow, airflow-pgbouncer, Service (v1) has been added:
+airflow, airflow-statsd, ConfigMap (v1) has been removed:
+`,
+			expected: []int{1, 2, 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var added, changed, removed int
+			if test.tool == "terraform" {
+				added, changed, removed = wkspace.CountTerraformChanges(test.output)
+			} else {
+				added, changed, removed = wkspace.CountHelmChanges(test.output)
+			}
+			assert.Equal(t, test.expected, []int{added, changed, removed})
+		})
+	}
+}
+
+func TestRepoDrift(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []*wkspace.DriftResult
+		expected wkspace.DriftStatus
+	}{
+		{
+			name:     `test repo without drift`,
+			results:  []*wkspace.DriftResult{{Tool: "terraform", Status: wkspace.DriftInSync}, {Tool: "helm", Status: wkspace.DriftInSync}},
+			expected: wkspace.DriftInSync,
+		},
+		{
+			name:     `test repo with drift in one tool`,
+			results:  []*wkspace.DriftResult{{Tool: "terraform", Status: wkspace.DriftInSync}, {Tool: "helm", Status: wkspace.DriftDrifted}},
+			expected: wkspace.DriftDrifted,
+		},
+		{
+			name:     `test errors take precedence over drift`,
+			results:  []*wkspace.DriftResult{{Tool: "terraform", Status: wkspace.DriftError}, {Tool: "helm", Status: wkspace.DriftDrifted}},
+			expected: wkspace.DriftError,
+		},
+		{
+			name:     `test tools the repo doesn't use are skipped`,
+			results:  []*wkspace.DriftResult{nil, {Tool: "helm", Status: wkspace.DriftInSync}},
+			expected: wkspace.DriftInSync,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drift := wkspace.NewRepoDrift("airflow", test.results...)
+			assert.Equal(t, test.expected, drift.Status)
+			assert.Nil(t, drift.Result("kustomize"))
+		})
+	}
+}
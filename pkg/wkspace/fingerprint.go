@@ -0,0 +1,184 @@
+package wkspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+	"gopkg.in/yaml.v2"
+)
+
+// Fingerprint hashes each upstream input of a repo's build, so builds whose inputs haven't changed
+// can be skipped, and rebuilds can say which input changed
+type Fingerprint struct {
+	Charts       string `hcl:"charts"`
+	Terraform    string `hcl:"terraform"`
+	Values       string `hcl:"values"`
+	Context      string `hcl:"context"`
+	Provider     string `hcl:"provider"`
+	Installation string `hcl:"installation"`
+	Applications string `hcl:"applications"`
+	Build        string `hcl:"build"`
+}
+
+// Fingerprint computes the build fingerprint of this workspace from what its values templates are
+// rendered with: chart and terraform versions, the templates themselves or their linked overrides, the
+// repo's section of context.yaml, the provider context, the installation, the namespace prefix and
+// endpoint of the plural config, the values and outputs of the repos it depends on, and the cli version
+// and merge strategy doing the build.  Credentials and the rest of the config are left out, so builds
+// by different users or profiles fingerprint the same
+func (wk *Workspace) Fingerprint(root, version, strategy string) (*Fingerprint, error) {
+	charts := make([]string, 0, len(wk.Charts))
+	chartTemplates := map[string]string{}
+	for _, ci := range wk.Charts {
+		charts = append(charts, ci.Chart.Name+":"+ci.Version.Id)
+		chartTemplates[ci.Chart.Name] = ci.Version.ValuesTemplate
+	}
+
+	terraform := make([]string, 0, len(wk.Terraform))
+	tfTemplates := map[string]string{}
+	for _, ti := range wk.Terraform {
+		terraform = append(terraform, ti.Terraform.Name+":"+ti.Version.Id)
+		tfTemplates[ti.Terraform.Name] = ti.Version.ValuesTemplate
+	}
+	sort.Strings(charts)
+	sort.Strings(terraform)
+
+	if wk.Links != nil {
+		for name, path := range wk.Links.Helm {
+			chartTemplates[name] = linkedTemplate(path, "values.yaml.tpl")
+		}
+		for name, path := range wk.Links.Terraform {
+			tfTemplates[name] = linkedTemplate(path, "terraform.tfvars")
+		}
+	}
+
+	repo := wk.Installation.Repository.Name
+	namespace, prefix, endpoint := repo, "", ""
+	if wk.Config != nil {
+		namespace, prefix, endpoint = wk.Config.Namespace(repo), wk.Config.NamespacePrefix, wk.Config.Endpoint
+	}
+
+	applications, err := applicationState(root, repo, buildDependencies(repo, wk.Charts, wk.Terraform))
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := []interface{}{
+		charts,
+		terraform,
+		map[string]interface{}{"helm": chartTemplates, "terraform": tfTemplates},
+		map[string]interface{}{"configuration": wk.Context.Configuration[repo], "smtp": wk.Context.SMTP},
+		map[string]interface{}{
+			"provider": wk.Provider.Name(),
+			"cluster":  wk.Provider.Cluster(),
+			"project":  wk.Provider.Project(),
+			"region":   wk.Provider.Region(),
+			"bucket":   wk.Provider.Bucket(),
+			"context":  wk.Provider.Context(),
+			"network":  wk.Manifest.Network,
+		},
+		map[string]interface{}{
+			"license": wk.Installation.LicenseKey,
+			"oidc":    wk.Installation.OIDCProvider,
+			"acme":    wk.Installation.AcmeKeyId,
+			"secret":  wk.Installation.AcmeSecret,
+		},
+		applications,
+		map[string]interface{}{
+			"version":   version,
+			"strategy":  strategy,
+			"prefix":    prefix,
+			"endpoint":  endpoint,
+			"namespace": namespace,
+		},
+	}
+
+	hashes := make([]string, len(inputs))
+	for i, input := range inputs {
+		// yaml sorts map keys, so equal inputs always hash the same
+		contents, err := yaml.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = utils.Sha(contents)
+	}
+
+	return &Fingerprint{
+		Charts:       hashes[0],
+		Terraform:    hashes[1],
+		Values:       hashes[2],
+		Context:      hashes[3],
+		Provider:     hashes[4],
+		Installation: hashes[5],
+		Applications: hashes[6],
+		Build:        hashes[7],
+	}, nil
+}
+
+// linkedTemplate reads the template of a local link, so edits to it trigger rebuilds
+func linkedTemplate(dir, file string) string {
+	contents, err := utils.ReadFile(pathing.SanitizeFilepath(filepath.Join(dir, file)))
+	if err != nil {
+		return "unreadable link " + dir
+	}
+	return contents
+}
+
+// applicationState hashes the helm values and terraform outputs of the repo's dependencies, which
+// templates can read through Applications.  Dependencies that aren't installed under root are skipped
+func applicationState(root, repo string, deps []*manifest.Dependency) (map[string]string, error) {
+	state := map[string]string{}
+	if root == "" {
+		return state, nil
+	}
+
+	for _, dep := range deps {
+		for _, path := range []string{
+			filepath.Join(root, dep.Repo, "helm", dep.Repo, "values.yaml"),
+			filepath.Join(root, dep.Repo, "output.yaml"),
+		} {
+			contents, err := ioutil.ReadFile(pathing.SanitizeFilepath(path))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			rel, _ := filepath.Rel(root, path)
+			state[filepath.ToSlash(rel)] = utils.Sha(contents)
+		}
+	}
+	return state, nil
+}
+
+// Changes lists the inputs that differ from a previous fingerprint, which is nil if the repo was never
+// successfully built
+func (f *Fingerprint) Changes(prev *Fingerprint) []string {
+	if prev == nil {
+		return []string{"no previous build"}
+	}
+
+	changes := []string{}
+	for _, input := range []struct {
+		current, prev, reason string
+	}{
+		{f.Charts, prev.Charts, "chart versions changed"},
+		{f.Terraform, prev.Terraform, "terraform versions changed"},
+		{f.Values, prev.Values, "values templates changed"},
+		{f.Context, prev.Context, "context.yaml changed"},
+		{f.Provider, prev.Provider, "provider context changed"},
+		{f.Installation, prev.Installation, "installation changed"},
+		{f.Applications, prev.Applications, "values or outputs of dependencies changed"},
+		{f.Build, prev.Build, "cli version, merge strategy or namespace config changed"},
+	} {
+		if input.current != input.prev {
+			changes = append(changes, input.reason)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,142 @@
+package wkspace_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/config"
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/provider"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func workspace(chartVersion, template string, values map[string]interface{}) *wkspace.Workspace {
+	return &wkspace.Workspace{
+		Provider:     &provider.KINDProvider{Clust: "test", Proj: "test", Reg: "local"},
+		Installation: &api.Installation{Repository: &api.Repository{Name: "airflow"}},
+		Charts: []*api.ChartInstallation{
+			{
+				Chart:   &api.Chart{Name: "airflow", Dependencies: &api.Dependencies{Dependencies: []*api.Dependency{{Repo: "postgres"}}}},
+				Version: &api.Version{Id: chartVersion, ValuesTemplate: template},
+			},
+		},
+		Config:   &config.Config{Email: "me@plural.sh", Token: "token"},
+		Manifest: &manifest.ProjectManifest{},
+		Context: &manifest.Context{
+			Configuration: map[string]map[string]interface{}{"airflow": values},
+		},
+	}
+}
+
+func TestFingerprintChanges(t *testing.T) {
+	base := workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"})
+
+	otherRepo := workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"})
+	otherRepo.Context.Configuration["postgres"] = map[string]interface{}{"size": "10Gi"}
+
+	otherUser := workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"})
+	otherUser.Config = &config.Config{Email: "someone@plural.sh", Token: "refreshed", Notifications: []*config.Webhook{{Url: "https://hooks.example.com"}}}
+
+	prefixed := workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"})
+	prefixed.Config = &config.Config{Email: "me@plural.sh", Token: "token", NamespacePrefix: "dev-"}
+
+	tests := []struct {
+		name     string
+		prev     *wkspace.Workspace
+		version  string
+		expected []string
+	}{
+		{
+			name:     `test unchanged inputs`,
+			prev:     workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"}),
+			expected: []string{},
+		},
+		{
+			name:     `test new chart version`,
+			prev:     workspace("v0", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"}),
+			expected: []string{"chart versions changed"},
+		},
+		{
+			name:     `test changed template and context`,
+			prev:     workspace("v1", "replicas: 2", map[string]interface{}{"hostname": "old.example.com"}),
+			expected: []string{"values templates changed", "context.yaml changed"},
+		},
+		{
+			name:     `test configuration of other repos`,
+			prev:     otherRepo,
+			expected: []string{},
+		},
+		{
+			name:     `test credentials and notifications of another user`,
+			prev:     otherUser,
+			expected: []string{},
+		},
+		{
+			name:     `test namespace prefix`,
+			prev:     prefixed,
+			expected: []string{"cli version, merge strategy or namespace config changed"},
+		},
+		{
+			name:     `test new cli version`,
+			prev:     workspace("v1", "replicas: 1", map[string]interface{}{"hostname": "airflow.example.com"}),
+			version:  "0.4.0",
+			expected: []string{"cli version, merge strategy or namespace config changed"},
+		},
+	}
+
+	current, err := base.Fingerprint("", "0.5.0", "theirs")
+	assert.NoError(t, err)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.version == "" {
+				test.version = "0.5.0"
+			}
+			prev, err := test.prev.Fingerprint("", test.version, "theirs")
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, current.Changes(prev))
+		})
+	}
+
+	assert.Equal(t, []string{"no previous build"}, current.Changes(nil))
+}
+
+func TestFingerprintApplications(t *testing.T) {
+	root, err := ioutil.TempDir("", "fingerprint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	write := func(path, contents string) {
+		path = filepath.Join(root, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+	write("airflow/helm/airflow/values.yaml", "airflow:\n  enabled: true\n")
+	write("postgres/helm/postgres/values.yaml", "postgres:\n  enabled: true\n")
+	write("postgres/output.yaml", "terraform:\n  endpoint: db.internal\n")
+	write("redis/output.yaml", "terraform:\n  endpoint: cache.internal\n")
+
+	wk := workspace("v1", "replicas: 1", map[string]interface{}{})
+	base, err := wk.Fingerprint(root, "0.5.0", "theirs")
+	assert.NoError(t, err)
+
+	// the repo's own values are the build's output, not an input
+	write("airflow/helm/airflow/values.yaml", "airflow:\n  enabled: false\n")
+	same, err := wk.Fingerprint(root, "0.5.0", "theirs")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, same.Changes(base))
+
+	// repos it doesn't depend on aren't inputs either
+	write("redis/output.yaml", "terraform:\n  endpoint: cache2.internal\n")
+	same, err = wk.Fingerprint(root, "0.5.0", "theirs")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, same.Changes(base))
+
+	write("postgres/output.yaml", "terraform:\n  endpoint: db2.internal\n")
+	changed, err := wk.Fingerprint(root, "0.5.0", "theirs")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"values or outputs of dependencies changed"}, changed.Changes(base))
+}
@@ -3,9 +3,9 @@ package wkspace
 import (
 	"fmt"
 
-	toposort "github.com/philopon/go-toposort"
 	"github.com/pluralsh/plural/pkg/api"
 	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/utils"
 )
 
 type depsFetcher func(string) ([]*manifest.Dependency, error)
@@ -21,17 +21,24 @@ func SortAndFilter(installations []*api.Installation) ([]string, error) {
 	return TopSortNames(names)
 }
 
+// TopSort sorts installations by their dependencies.  Their packages are prefetched concurrently, and
+// stay cached for later calls if client is a PackageCache
 func TopSort(client api.Client, installations []*api.Installation) ([]*api.Installation, error) {
 	var repoMap = make(map[string]*api.Installation)
 	var depsMap = make(map[string][]*manifest.Dependency)
 	names := make([]string, len(installations))
 
+	cache := NewPackageCache(client)
+	if err := cache.Prefetch(installations); err != nil {
+		return nil, err
+	}
+
 	for i, installation := range installations {
 		repo := installation.Repository.Name
 		repoMap[repo] = installation
 		names[i] = repo
 
-		ci, tf, err := client.GetPackageInstallations(installation.Repository.Id)
+		ci, tf, err := cache.GetPackageInstallations(installation.Repository.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -59,19 +66,60 @@ func TopSort(client api.Client, installations []*api.Installation) ([]*api.Insta
 }
 
 func TopSortNames(repos []string) ([]string, error) {
-	return topsorter(repos, func(repo string) ([]*manifest.Dependency, error) {
-		man, err := manifest.Read(manifestPath(repo))
-		if err != nil {
-			return nil, err
-		}
+	return topsorter(repos, manifestDependencies)
+}
+
+// TopSortLayers groups repos into dependency layers.  Every repo only depends on repos in earlier
+// layers, so the repos within a single layer can be deployed independently of each other
+func TopSortLayers(repos []string) ([][]string, error) {
+	return layerer(repos, manifestDependencies)
+}
+
+func manifestDependencies(repo string) ([]*manifest.Dependency, error) {
+	man, err := manifest.Read(manifestPath(repo))
+	if err != nil {
+		return nil, err
+	}
 
-		return man.Dependencies, nil
+	return man.Dependencies, nil
+}
+
+func layerer(repos []string, fn depsFetcher) ([][]string, error) {
+	depsMap := make(map[string][]*manifest.Dependency)
+	sorted, err := topsorter(repos, func(repo string) ([]*manifest.Dependency, error) {
+		deps, err := fn(repo)
+		depsMap[repo] = deps
+		return deps, err
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// a repo's layer is one past the deepest of its dependencies, which the topsort guarantees
+	// have already been placed
+	depth := make(map[string]int)
+	layers := make([][]string, 0)
+	for _, repo := range sorted {
+		level := 0
+		for _, dep := range depsMap[repo] {
+			if d, ok := depth[dep.Repo]; ok && d+1 > level {
+				level = d + 1
+			}
+		}
+
+		depth[repo] = level
+		if level == len(layers) {
+			layers = append(layers, []string{})
+		}
+		layers[level] = append(layers[level], repo)
+	}
+
+	return layers, nil
 }
 
 func topsorter(repos []string, fn depsFetcher) ([]string, error) {
 	seen := make(map[string]bool)
-	graph := toposort.NewGraph(len(repos))
+	graph := utils.Graph(len(repos))
 	isRepo := make(map[string]bool)
 	for _, repo := range repos {
 		isRepo[repo] = true
@@ -101,9 +149,9 @@ func topsorter(repos []string, fn depsFetcher) ([]string, error) {
 		}
 	}
 
-	sorted, ok := graph.Toposort()
+	sorted, ok := graph.Topsort()
 	if !ok {
-		return nil, fmt.Errorf("Cycle detected in dependency graph")
+		return nil, fmt.Errorf("Cycle detected in dependency graph, repos depend on each other in the cycle %s", utils.FormatCycle(graph.Cycle()))
 	}
 
 	// need to reverse the order
@@ -0,0 +1,71 @@
+package wkspace_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/manifest"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopSortLayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		deps     map[string][]string
+		repos    []string
+		expected [][]string
+	}{
+		{
+			name:     `test independent repos share a layer`,
+			deps:     map[string][]string{"bootstrap": {}, "airflow": {"bootstrap"}, "grafana": {"bootstrap"}},
+			repos:    []string{"airflow", "bootstrap", "grafana"},
+			expected: [][]string{{"bootstrap"}, {"airflow", "grafana"}},
+		},
+		{
+			name:     `test chained dependencies produce separate layers`,
+			deps:     map[string][]string{"bootstrap": {}, "postgres": {"bootstrap"}, "airflow": {"bootstrap", "postgres"}},
+			repos:    []string{"airflow", "bootstrap", "postgres"},
+			expected: [][]string{{"bootstrap"}, {"postgres"}, {"airflow"}},
+		},
+		{
+			name:     `test dependencies outside the deployed set are ignored`,
+			deps:     map[string][]string{"airflow": {"bootstrap"}, "grafana": {"bootstrap"}},
+			repos:    []string{"airflow", "grafana"},
+			expected: [][]string{{"airflow", "grafana"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "wkspace")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			wd, err := os.Getwd()
+			assert.NoError(t, err)
+			err = os.Chdir(dir)
+			assert.NoError(t, err)
+			defer func() { _ = os.Chdir(wd) }()
+
+			for repo, deps := range test.deps {
+				man := &manifest.Manifest{Name: repo}
+				for _, dep := range deps {
+					man.Dependencies = append(man.Dependencies, &manifest.Dependency{Repo: dep})
+				}
+				err := os.MkdirAll(repo, 0755)
+				assert.NoError(t, err)
+				err = man.Write(filepath.Join(repo, "manifest.yaml"))
+				assert.NoError(t, err)
+			}
+
+			layers, err := wkspace.TopSortLayers(test.repos)
+			assert.NoError(t, err)
+			assert.Equal(t, len(test.expected), len(layers))
+			for i, layer := range test.expected {
+				assert.ElementsMatch(t, layer, layers[i])
+			}
+		})
+	}
+}
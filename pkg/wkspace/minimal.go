@@ -3,6 +3,7 @@ package wkspace
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -100,8 +101,70 @@ func (m *MinimalWorkspace) BounceHelm(extraArgs ...string) error {
 	defaultArgs := []string{"upgrade", "--install", "--skip-crds", "--timeout", "10m", "--namespace", namespace, m.Name, path}
 	args = append(args, defaultArgs...)
 	args = append(args, extraArgs...)
-	return utils.Cmd(m.Config,
-		"helm", args...)
+	if err := utils.Cmd(m.Config, "helm", args...); err != nil {
+		return err
+	}
+
+	// values are still templated here, so the snapshot matches exactly what was deployed
+	if err := m.snapshotManifests(path, namespace); err != nil {
+		utils.Warn("could not snapshot the rendered manifests for offline diffs: %s\n", err)
+	}
+	return nil
+}
+
+// snapshotManifests stores the masked output of `helm template` for the chart at path, for later offline diffs
+func (m *MinimalWorkspace) snapshotManifests(path, namespace string) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	rendered, err := renderChart(m.Config, m.Name, namespace, path)
+	if err != nil {
+		return err
+	}
+
+	masked, err := diff.MaskManifests(rendered)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(diff.SnapshotPath(root, m.Name), masked, 0644)
+}
+
+// OfflineHelmDiff renders the repo's chart as it would be deployed now and diffs it object by object
+// against the manifests snapshotted by its last successful bounce, without needing cluster access
+func OfflineHelmDiff(root, repo string) ([]*diff.ObjectChange, error) {
+	snapshot, err := ioutil.ReadFile(diff.SnapshotPath(root, repo))
+	if err != nil {
+		return nil, fmt.Errorf("no rendered manifests from a previous deploy of %s, deploy it once before using offline diffs: %w", repo, err)
+	}
+
+	path := pathing.SanitizeFilepath(filepath.Join(root, repo, "helm", repo))
+	backup, err := templateVals(repo, path)
+	if err == nil {
+		defer func(oldpath, newpath string) {
+			_ = os.Rename(oldpath, newpath)
+		}(backup, pathing.SanitizeFilepath(filepath.Join(path, "values.yaml")))
+	}
+
+	conf := config.Read()
+	rendered, err := renderChart(&conf, repo, conf.Namespace(repo), path)
+	if err != nil {
+		return nil, err
+	}
+	return diff.DiffManifests(snapshot, rendered)
+}
+
+func renderChart(conf *config.Config, name, namespace, path string) ([]byte, error) {
+	cmd := utils.MkCmd(conf, "helm", "template", "--skip-crds", "--namespace", namespace, name, path)
+	cmd.Stdout = nil
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm template failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
 }
 
 func (m *MinimalWorkspace) TemplateHelm() error {
@@ -141,12 +204,66 @@ func (m *MinimalWorkspace) DiffHelm() error {
 	utils.Warn("helm diff upgrade --install --show-secrets --reset-values --namespace %s %s %s\n", namespace, m.Name, path)
 	if err := m.runDiff("helm", "diff", "upgrade", "--show-secrets", "--reset-values", "--install", "--namespace", namespace, m.Name, path); err != nil {
 		utils.Note("helm diff failed, this command can be flaky, but let us know regardless")
+		return nil
+	}
+
+	if err := m.summarizeHelm(); err != nil {
+		utils.Warn("could not summarize the helm diff: %s\n", err)
 	}
 	return nil
 }
 
 func (m *MinimalWorkspace) DiffTerraform() error {
-	return m.runDiff("terraform", "plan")
+	plan, err := ioutil.TempFile("", "plan")
+	if err != nil {
+		return err
+	}
+	_ = plan.Close()
+	defer os.Remove(plan.Name())
+
+	if err := m.runDiff("terraform", "plan", "-out", plan.Name()); err != nil {
+		return err
+	}
+
+	if err := m.summarizeTerraform(plan.Name()); err != nil {
+		utils.Warn("could not summarize the terraform plan: %s\n", err)
+	}
+	return nil
+}
+
+// summarizeTerraform writes a masked summary of a saved plan next to the raw diff
+func (m *MinimalWorkspace) summarizeTerraform(plan string) error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("terraform", "show", "-json", plan)
+	cmd.Dir = pathing.SanitizeFilepath(filepath.Join(root, m.Name, "terraform"))
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	changes, err := diff.ParseTerraformPlan(out)
+	if err != nil {
+		return err
+	}
+	return diff.WriteSummary(diff.SummaryPath(root, m.Name, diff.TerraformSummary), changes)
+}
+
+// summarizeHelm writes the kubernetes objects changed in the raw helm diff, without their contents
+func (m *MinimalWorkspace) summarizeHelm() error {
+	root, err := git.Root()
+	if err != nil {
+		return err
+	}
+
+	contents, err := ioutil.ReadFile(diff.SummaryPath(root, m.Name, "helm"))
+	if err != nil {
+		return err
+	}
+	return diff.WriteSummary(diff.SummaryPath(root, m.Name, diff.HelmSummary), diff.ParseHelmDiff(contents))
 }
 
 func (m *MinimalWorkspace) runDiff(command string, args ...string) error {
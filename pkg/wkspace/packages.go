@@ -0,0 +1,62 @@
+package wkspace
+
+import (
+	"sync"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"golang.org/x/sync/errgroup"
+)
+
+// MaxConcurrentFetches bounds how many api calls or downloads a build makes at once
+const MaxConcurrentFetches = 8
+
+// PackageCache wraps a client so the chart and terraform installations of each repo are only fetched
+// once, letting TopSort prefetch them concurrently for every workspace built afterwards
+type PackageCache struct {
+	api.Client
+	mut      sync.Mutex
+	packages map[string]*packageFetch
+}
+
+type packageFetch struct {
+	once      sync.Once
+	charts    []*api.ChartInstallation
+	terraform []*api.TerraformInstallation
+	err       error
+}
+
+func NewPackageCache(client api.Client) *PackageCache {
+	if cache, ok := client.(*PackageCache); ok {
+		return cache
+	}
+	return &PackageCache{Client: client, packages: make(map[string]*packageFetch)}
+}
+
+func (c *PackageCache) GetPackageInstallations(repoId string) ([]*api.ChartInstallation, []*api.TerraformInstallation, error) {
+	c.mut.Lock()
+	fetch, ok := c.packages[repoId]
+	if !ok {
+		fetch = &packageFetch{}
+		c.packages[repoId] = fetch
+	}
+	c.mut.Unlock()
+
+	fetch.once.Do(func() {
+		fetch.charts, fetch.terraform, fetch.err = c.Client.GetPackageInstallations(repoId)
+	})
+	return fetch.charts, fetch.terraform, fetch.err
+}
+
+// Prefetch fetches the packages of every installation, at most MaxConcurrentFetches at a time
+func (c *PackageCache) Prefetch(installations []*api.Installation) error {
+	var group errgroup.Group
+	group.SetLimit(MaxConcurrentFetches)
+	for _, installation := range installations {
+		id := installation.Repository.Id
+		group.Go(func() error {
+			_, _, err := c.GetPackageInstallations(id)
+			return err
+		})
+	}
+	return group.Wait()
+}
@@ -0,0 +1,39 @@
+package wkspace_test
+
+import (
+	"testing"
+
+	"github.com/pluralsh/plural/pkg/api"
+	"github.com/pluralsh/plural/pkg/test/mocks"
+	"github.com/pluralsh/plural/pkg/wkspace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageCache(t *testing.T) {
+	installations := []*api.Installation{
+		{Repository: &api.Repository{Id: "1", Name: "postgres"}},
+		{Repository: &api.Repository{Id: "2", Name: "airflow"}},
+	}
+	postgres := []*api.ChartInstallation{{Chart: &api.Chart{Name: "postgres", Dependencies: &api.Dependencies{}}}}
+	airflow := []*api.ChartInstallation{{
+		Chart: &api.Chart{Name: "airflow", Dependencies: &api.Dependencies{
+			Dependencies: []*api.Dependency{{Repo: "postgres"}},
+		}},
+	}}
+
+	client := mocks.NewClient(t)
+	client.On("GetPackageInstallations", "1").Return(postgres, []*api.TerraformInstallation{}, nil).Once()
+	client.On("GetPackageInstallations", "2").Return(airflow, []*api.TerraformInstallation{}, nil).Once()
+
+	cache := wkspace.NewPackageCache(client)
+	assert.Same(t, cache, wkspace.NewPackageCache(cache))
+
+	sorted, err := wkspace.TopSort(cache, []*api.Installation{installations[1], installations[0]})
+	assert.NoError(t, err)
+	assert.Equal(t, installations, sorted)
+
+	// later fetches, like the one in wkspace.New, are served from the cache
+	charts, _, err := cache.GetPackageInstallations("2")
+	assert.NoError(t, err)
+	assert.Equal(t, airflow, charts)
+}
@@ -0,0 +1,95 @@
+package wkspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pluralsh/plural/pkg/utils"
+	"github.com/pluralsh/plural/pkg/utils/pathing"
+)
+
+// TerraformImport is an existing resource to adopt into a repo's terraform state
+type TerraformImport struct {
+	Address string `yaml:"address"`
+	Id      string `yaml:"id"`
+}
+
+// ReleaseValues reads the user supplied values of an existing helm release
+func ReleaseValues(release, namespace string) (map[string]interface{}, error) {
+	cmd := exec.Command("helm", "get", "values", release, "--namespace", namespace, "--output", "json")
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("failed to get the values of helm release %s in %s: %s", release, namespace, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	vals := map[string]interface{}{}
+	// helm prints null for a release installed without any values
+	if err := json.Unmarshal(out, &vals); err != nil {
+		return nil, fmt.Errorf("failed to parse the values of helm release %s: %w", release, err)
+	}
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+	return vals, nil
+}
+
+// ImportTerraform runs `terraform import` for each resource not already in the repo's terraform state
+// under root, returning the resources it imported
+func (m *MinimalWorkspace) ImportTerraform(root string, imports []*TerraformImport) ([]*TerraformImport, error) {
+	imported := make([]*TerraformImport, 0)
+	if len(imports) == 0 {
+		return imported, nil
+	}
+
+	dir := pathing.SanitizeFilepath(filepath.Join(root, m.Name, "terraform"))
+	if !utils.Exists(dir) {
+		return nil, fmt.Errorf("%s has no terraform to import resources into, run `plural build --only %s` first", m.Name, m.Name)
+	}
+
+	if err := runTerraform(dir, "init", "-input=false"); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("terraform", "state", "list")
+	cmd.Dir = dir
+	out, err := utils.ExecuteWithOutput(cmd)
+	if err != nil {
+		return nil, err
+	}
+	state := map[string]bool{}
+	for _, address := range strings.Fields(out) {
+		state[address] = true
+	}
+
+	for _, imp := range imports {
+		if state[imp.Address] {
+			utils.Note("%s is already in the terraform state of %s, skipping\n", imp.Address, m.Name)
+			continue
+		}
+
+		utils.Highlight("importing %s as %s\n", imp.Id, imp.Address)
+		if err := runTerraform(dir, "import", "-input=false", imp.Address, imp.Id); err != nil {
+			return imported, err
+		}
+		imported = append(imported, imp)
+	}
+	return imported, nil
+}
+
+func runTerraform(dir string, args ...string) error {
+	cmd := exec.Command("terraform", args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("terraform %s failed: %w", args[0], err)
+	}
+	return nil
+}
@@ -77,6 +77,10 @@ func (wk *Workspace) match(prov string) bool {
 		return prov == "AWS"
 	case provider.AZURE:
 		return prov == "AZURE"
+	case provider.EQUINIX:
+		return prov == "EQUINIX"
+	case provider.KIND:
+		return prov == "KIND"
 	default:
 		return false
 	}